@@ -0,0 +1,131 @@
+package bundle
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/builtin"
+	msig13 "github.com/filecoin-project/go-state-types/builtin/v13/multisig"
+	"github.com/filecoin-project/go-state-types/crypto"
+	"github.com/filecoin-project/lotus/chain/types"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/xerrors"
+	"os"
+)
+
+// Bundle is a pending multisig transaction handed between the coordinator and the signers on
+// air-gapped machines, with the approve messages of the signers and their signatures
+type Bundle struct {
+	Multisig address.Address `json:"multisig"`
+	TxID     uint64          `json:"txId"`
+	// Proposer is the id address of the signer that proposed the transaction
+	Proposer address.Address `json:"proposer"`
+	To       address.Address `json:"to"`
+	Value    abi.TokenAmount `json:"value"`
+	Method   abi.MethodNum   `json:"method"`
+	Params   []byte          `json:"params"`
+	// ProposalHash is the hex blake2b hash of the proposal hash data, the approvals pass it so they
+	// only apply to this transaction
+	ProposalHash string     `json:"proposalHash"`
+	Approvals    []Approval `json:"approvals,omitempty"`
+}
+
+// Approval is the unsigned approve message of a signer, and its signature once signed
+type Approval struct {
+	Signer    address.Address   `json:"signer"`
+	Message   *types.Message    `json:"message"`
+	Signature *crypto.Signature `json:"signature,omitempty"`
+}
+
+func Load(path string) (*Bundle, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var b Bundle
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, xerrors.Errorf("parsing bundle %s: %w", path, err)
+	}
+
+	if err := b.Verify(); err != nil {
+		return nil, xerrors.Errorf("invalid bundle %s: %w", path, err)
+	}
+
+	return &b, nil
+}
+
+func (b *Bundle) Save(path string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(data, '\n'), 0600)
+}
+
+// HashData is the proposal hash data of the transaction, as the multisig actor computes it
+func (b *Bundle) HashData() *msig13.ProposalHashData {
+	return &msig13.ProposalHashData{
+		Requester: b.Proposer,
+		To:        b.To,
+		Value:     b.Value,
+		Method:    b.Method,
+		Params:    b.Params,
+	}
+}
+
+func (b *Bundle) Hash() ([]byte, error) {
+	data, err := b.HashData().Serialize()
+	if err != nil {
+		return nil, err
+	}
+
+	h := blake2b.Sum256(data)
+	return h[:], nil
+}
+
+// Verify checks that the proposal hash matches the transaction in the bundle
+func (b *Bundle) Verify() error {
+	if b.Proposer.Protocol() != address.ID {
+		return xerrors.Errorf("proposer %s must be an id address", b.Proposer)
+	}
+
+	h, err := b.Hash()
+	if err != nil {
+		return err
+	}
+	if hex.EncodeToString(h) != b.ProposalHash {
+		return xerrors.Errorf("proposal hash %s doesn't match the transaction, expected %x", b.ProposalHash, h)
+	}
+
+	return nil
+}
+
+// CheckApproval checks that the message approves exactly the transaction of the bundle
+func (b *Bundle) CheckApproval(msg *types.Message) error {
+	if msg.To != b.Multisig {
+		return xerrors.Errorf("approve message is sent to %s, not the multisig %s", msg.To, b.Multisig)
+	}
+	if msg.Method != builtin.MethodsMultisig.Approve {
+		return xerrors.Errorf("approve message calls method %d, not Approve", msg.Method)
+	}
+	if !msg.Value.IsZero() {
+		return xerrors.Errorf("approve message sends %s, an approval sends nothing", types.FIL(msg.Value))
+	}
+
+	var p msig13.TxnIDParams
+	if err := p.UnmarshalCBOR(bytes.NewReader(msg.Params)); err != nil {
+		return xerrors.Errorf("decoding approve params: %w", err)
+	}
+	if uint64(p.ID) != b.TxID {
+		return xerrors.Errorf("approve message approves transaction %d, not %d", p.ID, b.TxID)
+	}
+	if hex.EncodeToString(p.ProposalHash) != b.ProposalHash {
+		return xerrors.Errorf("approve message approves proposal hash %x, not %s", p.ProposalHash, b.ProposalHash)
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package bundle
+
+import (
+	"encoding/hex"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/builtin"
+	msig13 "github.com/filecoin-project/go-state-types/builtin/v13/multisig"
+	"github.com/filecoin-project/lotus/chain/actors"
+	"github.com/filecoin-project/lotus/chain/types"
+	"path/filepath"
+	"testing"
+)
+
+func mustID(t *testing.T, id uint64) address.Address {
+	a, err := address.NewIDAddress(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func testBundle(t *testing.T) *Bundle {
+	b := &Bundle{
+		Multisig: mustID(t, 1000),
+		TxID:     3,
+		Proposer: mustID(t, 1001),
+		To:       mustID(t, 1002),
+		Value:    types.FromFil(5),
+		Method:   builtin.MethodSend,
+	}
+	h, err := b.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.ProposalHash = hex.EncodeToString(h)
+	return b
+}
+
+func approveMessage(t *testing.T, b *Bundle, txid uint64, hash string) *types.Message {
+	h, err := hex.DecodeString(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	params, err := actors.SerializeParams(&msig13.TxnIDParams{ID: msig13.TxnID(txid), ProposalHash: h})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &types.Message{
+		From:   mustID(t, 1003),
+		To:     b.Multisig,
+		Value:  abi.NewTokenAmount(0),
+		Method: builtin.MethodsMultisig.Approve,
+		Params: params,
+	}
+}
+
+func TestBundleRoundtrip(t *testing.T) {
+	b := testBundle(t)
+	b.Approvals = []Approval{{Signer: mustID(t, 1003), Message: approveMessage(t, b, b.TxID, b.ProposalHash)}}
+
+	path := filepath.Join(t.TempDir(), "bundle.json")
+	if err := b.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.TxID != b.TxID || !loaded.Value.Equals(b.Value) || len(loaded.Approvals) != 1 {
+		t.Fatalf("unexpected bundle %+v", loaded)
+	}
+	if err := loaded.CheckApproval(loaded.Approvals[0].Message); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded.Value = types.FromFil(50)
+	if err := loaded.Verify(); err == nil {
+		t.Fatal("expected a changed value to break the proposal hash")
+	}
+}
+
+func TestCheckApproval(t *testing.T) {
+	b := testBundle(t)
+
+	if err := b.CheckApproval(approveMessage(t, b, 4, b.ProposalHash)); err == nil {
+		t.Fatal("expected another txid to be refused")
+	}
+
+	other := testBundle(t)
+	other.Value = types.FromFil(6)
+	h, err := other.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.CheckApproval(approveMessage(t, b, b.TxID, hex.EncodeToString(h))); err == nil {
+		t.Fatal("expected another proposal hash to be refused")
+	}
+
+	msg := approveMessage(t, b, b.TxID, b.ProposalHash)
+	msg.Method = builtin.MethodsMultisig.Cancel
+	if err := b.CheckApproval(msg); err == nil {
+		t.Fatal("expected a cancel to be refused")
+	}
+}
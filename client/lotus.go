@@ -77,10 +77,10 @@ func LotusMpoolPush(rpcAddr, token string, signedMessage *types.SignedMessage) (
 	return cid.Undef, nil
 }
 
-func LotusGasEstimateMessageGas(rpcAddr, token string, message *types.Message, maxFee int64) (gasLimit float64, gasFeeCap, gasPremium string, err error) {
+func LotusGasEstimateMessageGas(rpcAddr, token string, message *types.Message, maxFee abi.TokenAmount) (gasLimit float64, gasFeeCap, gasPremium string, err error) {
 	var params []interface{}
 	params = append(params, message)
-	params = append(params, api.MessageSendSpec{MaxFee: abi.NewTokenAmount(maxFee)})
+	params = append(params, api.MessageSendSpec{MaxFee: maxFee})
 	params = append(params, types.EmptyTSK)
 
 	result, err := NewClient(rpcAddr, token, GasEstimateMessageGas, params).Call()
@@ -16,7 +16,7 @@ func TestLotusGasEstimateMessageGas(t *testing.T) {
 		Nonce:  1,
 		Method: 0,
 		Value:  abi.NewTokenAmount(1000000000000000000),
-	}, 1000000000000000000)
+	}, abi.NewTokenAmount(1000000000000000000))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -1,8 +1,12 @@
 package config
 
 import (
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/lotus/chain/types"
 	logging "github.com/ipfs/go-log/v2"
 	"github.com/spf13/viper"
+	"golang.org/x/xerrors"
 	"os"
 	"path/filepath"
 	"strings"
@@ -11,20 +15,48 @@ import (
 type Config struct {
 	Account Account `yaml:"account"`
 	Chain   Chain   `yaml:"chain"`
+	Policy  Policy  `yaml:"policy"`
+	Journal Journal `yaml:"journal"`
+	Signer  Signer  `yaml:"signer"`
+	Watch   Watch   `yaml:"watch"`
 }
 
 type Account struct {
-	Mnemonic  string `yaml:"mnemonic"`
-	Password  bool   `yaml:"password"`
-	Key       string `yaml:"key"`
-	KeyFormat string `yaml:"keyFormat"`
+	Mnemonic  string            `yaml:"mnemonic"`
+	Password  bool              `yaml:"password"`
+	Key       string            `yaml:"key"`
+	KeyFormat string            `yaml:"keyFormat"`
+	Labels    map[string]string `yaml:"labels"`
 }
 
 type Chain struct {
-	MaxFee   string `json:"maxFee"`
-	RpcAddr  string `yaml:"rpcAddr"`
-	Token    string `json:"token"`
-	Explorer string `yaml:"explorer"`
+	MaxFee      string `json:"maxFee"`
+	RpcAddr     string `yaml:"rpcAddr"`
+	Token       string `json:"token"`
+	Explorer    string `yaml:"explorer"`
+	FeeStrategy string `yaml:"feeStrategy"`
+	FeeCap      string `yaml:"feeCap"`
+	GasPremium  string `yaml:"gasPremium"`
+	// NetworkVersion is used when the node can't be reached, it must match the node otherwise
+	NetworkVersion uint `yaml:"networkVersion"`
+}
+
+type Policy struct {
+	Path   string `yaml:"path"`
+	Ledger string `yaml:"ledger"`
+}
+
+type Journal struct {
+	Path string `yaml:"path"`
+}
+
+type Signer struct {
+	Secret string `yaml:"secret"`
+}
+
+type Watch struct {
+	Webhook string `yaml:"webhook"`
+	Exec    string `yaml:"exec"`
 }
 
 var (
@@ -61,6 +93,34 @@ func InitConfig(confPath string) {
 		log.Errorf("Unmarshal fail: %+v", err)
 		os.Exit(1)
 	}
+
+	if _, err := ParseMaxFee(conf.Chain.MaxFee); err != nil {
+		log.Errorf("invalid chain.maxFee: %+v", err)
+		os.Exit(1)
+	}
+}
+
+// ParseMaxFee parses a max fee like 1FIL or 500000attoFIL, empty leaves the limit to the node default
+func ParseMaxFee(s string) (abi.TokenAmount, error) {
+	if s == "" {
+		return big.Zero(), nil
+	}
+
+	f, err := types.ParseFIL(s)
+	if err != nil {
+		return big.Zero(), err
+	}
+	if f.Int.Sign() < 0 {
+		return big.Zero(), xerrors.Errorf("max fee %s is negative", s)
+	}
+
+	return abi.TokenAmount(f), nil
+}
+
+// MaxFeeAmount returns the max fee, it's validated when the config is loaded
+func (c Chain) MaxFeeAmount() abi.TokenAmount {
+	maxFee, _ := ParseMaxFee(c.MaxFee)
+	return maxFee
 }
 
 func Conf() Config {
@@ -0,0 +1,34 @@
+package config
+
+import (
+	"github.com/filecoin-project/lotus/chain/types"
+	"testing"
+)
+
+func TestParseMaxFee(t *testing.T) {
+	maxFee, err := ParseMaxFee("20FIL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !maxFee.Equals(types.FromFil(20)) {
+		t.Fatalf("expected 20 FIL, got %s", types.FIL(maxFee))
+	}
+
+	maxFee, err = ParseMaxFee("1234567890123attoFIL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if maxFee.String() != "1234567890123" {
+		t.Fatalf("expected attoFIL precision, got %s", maxFee)
+	}
+
+	if maxFee, err = ParseMaxFee(""); err != nil || !maxFee.IsZero() {
+		t.Fatalf("expected empty max fee to be zero, got %s %v", maxFee, err)
+	}
+
+	for _, s := range []string{"1 apple", "-1FIL"} {
+		if _, err := ParseMaxFee(s); err == nil {
+			t.Fatalf("expected %q to be invalid", s)
+		}
+	}
+}
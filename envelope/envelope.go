@@ -0,0 +1,100 @@
+package envelope
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/crypto"
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+)
+
+const (
+	// ModeFRC102 signs the message behind the FRC-0102 header, so the signature can never pass as the
+	// signature of a chain message
+	ModeFRC102 = "frc102"
+	// ModeRaw signs the bytes as they are
+	ModeRaw = "raw"
+	// ModeLotus signs the bytes as they are, like `lotus wallet sign`
+	ModeLotus = "lotus"
+)
+
+// Prefix is the FRC-0102 header, followed by the decimal length of the message and the message
+const Prefix = "\x19Filecoin Signed Message:\n"
+
+// Payload returns the bytes signed for the message in the mode
+func Payload(mode string, data []byte) ([]byte, error) {
+	switch mode {
+	case ModeFRC102:
+		return append([]byte(fmt.Sprintf("%s%d", Prefix, len(data))), data...), nil
+	case ModeRaw, ModeLotus:
+		return data, nil
+	default:
+		return nil, xerrors.Errorf("unknown mode %s, must be %s / %s / %s", mode, ModeFRC102, ModeRaw, ModeLotus)
+	}
+}
+
+// IsCid reports whether the bytes are a cid, ps: of a chain message, signing them in the raw modes
+// signs whatever the cid points to
+func IsCid(data []byte) bool {
+	n, _, err := cid.CidFromBytes(data)
+	return err == nil && n == len(data)
+}
+
+// Signature is the json output of a signed message
+type Signature struct {
+	Address string `json:"address"`
+	Type    string `json:"type"`
+	Mode    string `json:"mode"`
+	// Signature is the base64 signature data
+	Signature string `json:"signature"`
+	// Hex is the type byte followed by the signature data, the format of `lotus wallet sign`
+	Hex string `json:"hex"`
+}
+
+func New(addr address.Address, mode string, sig *crypto.Signature) (*Signature, error) {
+	typ, err := sig.Type.Name()
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := sig.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Signature{
+		Address:   addr.String(),
+		Type:      typ,
+		Mode:      mode,
+		Signature: base64.StdEncoding.EncodeToString(sig.Data),
+		Hex:       hex.EncodeToString(b),
+	}, nil
+}
+
+// Decode returns the address and the signature, the hex and base64 signatures must agree
+func (s *Signature) Decode() (address.Address, *crypto.Signature, error) {
+	addr, err := address.NewFromString(s.Address)
+	if err != nil {
+		return address.Undef, nil, xerrors.Errorf("parsing address: %w", err)
+	}
+
+	b, err := hex.DecodeString(s.Hex)
+	if err != nil {
+		return address.Undef, nil, xerrors.Errorf("decoding hex signature: %w", err)
+	}
+	var sig crypto.Signature
+	if err := sig.UnmarshalBinary(b); err != nil {
+		return address.Undef, nil, err
+	}
+
+	if s.Signature != "" && s.Signature != base64.StdEncoding.EncodeToString(sig.Data) {
+		return address.Undef, nil, xerrors.New("the base64 and hex signatures differ")
+	}
+	if typ, err := sig.Type.Name(); err != nil || (s.Type != "" && typ != s.Type) {
+		return address.Undef, nil, xerrors.Errorf("signature type %s doesn't match the signature", s.Type)
+	}
+
+	return addr, &sig, nil
+}
@@ -0,0 +1,90 @@
+package envelope
+
+import (
+	"encoding/json"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/wallet/key"
+	"github.com/filecoin-project/lotus/lib/sigs"
+	_ "github.com/filecoin-project/lotus/lib/sigs/secp"
+	"testing"
+)
+
+func TestPayload(t *testing.T) {
+	p, err := Payload(ModeFRC102, []byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(p) != "\x19Filecoin Signed Message:\n5hello" {
+		t.Fatalf("unexpected payload %q", p)
+	}
+
+	p, err = Payload(ModeLotus, []byte("hello"))
+	if err != nil || string(p) != "hello" {
+		t.Fatalf("unexpected payload %q %v", p, err)
+	}
+
+	if _, err := Payload("eip191", nil); err == nil {
+		t.Fatal("expected an unknown mode to be refused")
+	}
+}
+
+func TestIsCid(t *testing.T) {
+	k, err := key.GenerateKey(types.KTSecp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := types.Message{From: k.Address, To: k.Address, Value: abi.NewTokenAmount(1)}
+
+	if !IsCid(msg.Cid().Bytes()) {
+		t.Fatal("expected the message cid to be detected")
+	}
+	if IsCid([]byte("hello")) {
+		t.Fatal("expected text not to be a cid")
+	}
+}
+
+func TestSignatureRoundtrip(t *testing.T) {
+	k, err := key.GenerateKey(types.KTSecp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload, err := Payload(ModeFRC102, []byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := sigs.Sign(key.ActSigType(k.Type), k.PrivateKey, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := New(k.Address, ModeFRC102, sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var parsed Signature
+	if err := json.Unmarshal(b, &parsed); err != nil {
+		t.Fatal(err)
+	}
+	addr, decoded, err := parsed.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr != k.Address || parsed.Type != "secp256k1" {
+		t.Fatalf("unexpected address %s type %s", addr, parsed.Type)
+	}
+	if err := sigs.Verify(decoded, addr, payload); err != nil {
+		t.Fatal(err)
+	}
+
+	parsed.Signature = "AAAA"
+	if _, _, err := parsed.Decode(); err == nil {
+		t.Fatal("expected mismatching signatures to be refused")
+	}
+}
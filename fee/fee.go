@@ -0,0 +1,130 @@
+package fee
+
+import (
+	"context"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/lotus/chain/types"
+	"golang.org/x/xerrors"
+)
+
+// Node is the part of the lotus full node api used to estimate fees, api.FullNode satisfies it
+type Node interface {
+	ChainHead(context.Context) (*types.TipSet, error)
+	ChainGetTipSet(context.Context, types.TipSetKey) (*types.TipSet, error)
+	GasEstimateGasPremium(_ context.Context, nblocksincl uint64, sender address.Address, gaslimit int64, tsk types.TipSetKey) (types.BigInt, error)
+}
+
+// Strategy decides how much premium and fee cap a message offers
+type Strategy struct {
+	Name string
+	// Blocks is the number of epochs the message should be included within, used to estimate the premium
+	Blocks uint64
+	// Lookback is the number of epochs of base fee history the fee cap is based on
+	Lookback int
+	// Multiplier of the highest base fee in the lookback, the fee cap absorbs base fee increases up to it
+	Multiplier int64
+}
+
+var (
+	Economy = Strategy{Name: "economy", Blocks: 20, Lookback: 1, Multiplier: 2}
+	Normal  = Strategy{Name: "normal", Blocks: 10, Lookback: 20, Multiplier: 3}
+	Urgent  = Strategy{Name: "urgent", Blocks: 2, Lookback: 20, Multiplier: 5}
+	// Fixed uses the fee cap and premium from the config as they are
+	Fixed = Strategy{Name: "fixed"}
+
+	Strategies = []Strategy{Economy, Normal, Urgent, Fixed}
+)
+
+func ParseStrategy(name string) (Strategy, error) {
+	for _, s := range Strategies {
+		if s.Name == name {
+			return s, nil
+		}
+	}
+
+	return Strategy{}, xerrors.Errorf("unknown fee strategy %s, ps: economy, normal, urgent, fixed", name)
+}
+
+// BaseFee is the base fee paid by the messages included at Height
+type BaseFee struct {
+	Height  abi.ChainEpoch
+	BaseFee abi.TokenAmount
+}
+
+// BaseFeeHistory returns the base fee of the last n tipsets, the latest first
+func BaseFeeHistory(ctx context.Context, node Node, n int) ([]BaseFee, error) {
+	ts, err := node.ChainHead(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var history []BaseFee
+	for len(history) < n {
+		history = append(history, BaseFee{Height: ts.Height(), BaseFee: ts.Blocks()[0].ParentBaseFee})
+		if ts.Height() == 0 {
+			break
+		}
+
+		ts, err = node.ChainGetTipSet(ctx, ts.Parents())
+		if err != nil {
+			return nil, xerrors.Errorf("getting parent tipset of %d: %w", history[len(history)-1].Height, err)
+		}
+	}
+
+	return history, nil
+}
+
+// MaxBaseFee returns the highest base fee of the history
+func MaxBaseFee(history []BaseFee) abi.TokenAmount {
+	m := big.Zero()
+	for _, h := range history {
+		m = big.Max(m, h.BaseFee)
+	}
+	return m
+}
+
+// Estimate returns the fee cap and premium the strategy offers for a message with the given sender and gas limit
+func (s Strategy) Estimate(ctx context.Context, node Node, from address.Address, gasLimit int64) (feeCap, premium abi.TokenAmount, err error) {
+	if s.Blocks == 0 {
+		return big.Zero(), big.Zero(), xerrors.Errorf("fee strategy %s can't estimate fees", s.Name)
+	}
+
+	history, err := BaseFeeHistory(ctx, node, s.Lookback)
+	if err != nil {
+		return big.Zero(), big.Zero(), err
+	}
+
+	return s.EstimateWithHistory(ctx, node, history, from, gasLimit)
+}
+
+// EstimateWithHistory is Estimate with a base fee history fetched once for several estimates, only
+// the lookback of the strategy is used from it
+func (s Strategy) EstimateWithHistory(ctx context.Context, node Node, history []BaseFee, from address.Address, gasLimit int64) (feeCap, premium abi.TokenAmount, err error) {
+	if s.Blocks == 0 {
+		return big.Zero(), big.Zero(), xerrors.Errorf("fee strategy %s can't estimate fees", s.Name)
+	}
+
+	if len(history) > s.Lookback {
+		history = history[:s.Lookback]
+	}
+
+	premium, err = node.GasEstimateGasPremium(ctx, s.Blocks, from, gasLimit, types.EmptyTSK)
+	if err != nil {
+		return big.Zero(), big.Zero(), xerrors.Errorf("estimating gas premium: %w", err)
+	}
+
+	feeCap = big.Add(big.Mul(MaxBaseFee(history), big.NewInt(s.Multiplier)), premium)
+	return feeCap, premium, nil
+}
+
+// CapFee lowers the fee cap so that the message never pays more than maxFee, and the premium to the fee cap
+func CapFee(msg *types.Message, maxFee abi.TokenAmount) {
+	gasLimit := big.NewInt(msg.GasLimit)
+	if maxFee.GreaterThan(big.Zero()) && big.Mul(msg.GasFeeCap, gasLimit).GreaterThan(maxFee) {
+		msg.GasFeeCap = big.Div(maxFee, gasLimit)
+	}
+
+	msg.GasPremium = big.Min(msg.GasFeeCap, msg.GasPremium)
+}
@@ -0,0 +1,149 @@
+package fee
+
+import (
+	"context"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+	"testing"
+)
+
+// mockNode serves a chain of single block tipsets with the given base fees, the first one is the head
+type mockNode struct {
+	tipsets  []*types.TipSet
+	premiums map[uint64]int64
+}
+
+func newMockNode(t *testing.T, baseFees ...int64) *mockNode {
+	c, err := cid.V1Builder{Codec: cid.DagCBOR, MhType: 0x12 /* sha2-256 */}.Sum([]byte("fee"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	miner, _ := address.NewIDAddress(1234)
+
+	n := &mockNode{premiums: map[uint64]int64{20: 100, 10: 200, 2: 1000}}
+
+	var parents []cid.Cid
+	tipsets := make([]*types.TipSet, len(baseFees))
+	for i := len(baseFees) - 1; i >= 0; i-- {
+		blk := &types.BlockHeader{
+			Miner:                 miner,
+			Height:                abi.ChainEpoch(len(baseFees) - 1 - i),
+			Parents:               parents,
+			ParentStateRoot:       c,
+			ParentMessageReceipts: c,
+			Messages:              c,
+			ParentBaseFee:         abi.NewTokenAmount(baseFees[i]),
+		}
+		ts, err := types.NewTipSet([]*types.BlockHeader{blk})
+		if err != nil {
+			t.Fatal(err)
+		}
+		tipsets[i] = ts
+		parents = ts.Cids()
+	}
+	n.tipsets = tipsets
+
+	return n
+}
+
+func (m *mockNode) ChainHead(context.Context) (*types.TipSet, error) {
+	return m.tipsets[0], nil
+}
+
+func (m *mockNode) ChainGetTipSet(_ context.Context, tsk types.TipSetKey) (*types.TipSet, error) {
+	for _, ts := range m.tipsets {
+		if ts.Key() == tsk {
+			return ts, nil
+		}
+	}
+	return nil, xerrors.Errorf("tipset %s not found", tsk)
+}
+
+func (m *mockNode) GasEstimateGasPremium(_ context.Context, nblocksincl uint64, _ address.Address, _ int64, _ types.TipSetKey) (types.BigInt, error) {
+	return big.NewInt(m.premiums[nblocksincl]), nil
+}
+
+func TestBaseFeeHistory(t *testing.T) {
+	node := newMockNode(t, 100, 300, 200)
+
+	history, err := BaseFeeHistory(context.Background(), node, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 3 {
+		t.Fatalf("expected the history to stop at genesis, got %d entries", len(history))
+	}
+	if history[0].Height != 2 || !history[0].BaseFee.Equals(big.NewInt(100)) {
+		t.Fatalf("expected the head first, got %d %s", history[0].Height, history[0].BaseFee)
+	}
+	if !MaxBaseFee(history).Equals(big.NewInt(300)) {
+		t.Fatalf("expected max base fee 300, got %s", MaxBaseFee(history))
+	}
+}
+
+func TestEstimate(t *testing.T) {
+	node := newMockNode(t, 100, 300, 200)
+
+	cases := []struct {
+		strategy        Strategy
+		feeCap, premium int64
+	}{
+		{Economy, 100*2 + 100, 100},
+		{Normal, 300*3 + 200, 200},
+		{Urgent, 300*5 + 1000, 1000},
+	}
+	for _, c := range cases {
+		feeCap, premium, err := c.strategy.Estimate(context.Background(), node, address.Undef, 1000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !feeCap.Equals(big.NewInt(c.feeCap)) || !premium.Equals(big.NewInt(c.premium)) {
+			t.Fatalf("%s: expected fee cap %d premium %d, got %s %s", c.strategy.Name, c.feeCap, c.premium, feeCap, premium)
+		}
+	}
+
+	if _, _, err := Fixed.Estimate(context.Background(), node, address.Undef, 1000); err == nil {
+		t.Fatal("fixed strategy must not estimate fees")
+	}
+
+	// a longer history than the lookback of the strategy is cut to it
+	history, err := BaseFeeHistory(context.Background(), node, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	feeCap, _, err := Economy.EstimateWithHistory(context.Background(), node, history, address.Undef, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !feeCap.Equals(big.NewInt(100*2 + 100)) {
+		t.Fatalf("expected economy to only use the head base fee, got fee cap %s", feeCap)
+	}
+}
+
+func TestCapFee(t *testing.T) {
+	msg := &types.Message{GasLimit: 1000, GasFeeCap: big.NewInt(500), GasPremium: big.NewInt(400)}
+	CapFee(msg, big.NewInt(300*1000))
+	if !msg.GasFeeCap.Equals(big.NewInt(300)) || !msg.GasPremium.Equals(big.NewInt(300)) {
+		t.Fatalf("expected fee cap and premium capped at 300, got %s %s", msg.GasFeeCap, msg.GasPremium)
+	}
+
+	msg = &types.Message{GasLimit: 1000, GasFeeCap: big.NewInt(500), GasPremium: big.NewInt(400)}
+	CapFee(msg, big.NewInt(1000*1000))
+	if !msg.GasFeeCap.Equals(big.NewInt(500)) || !msg.GasPremium.Equals(big.NewInt(400)) {
+		t.Fatalf("expected fees under max fee unchanged, got %s %s", msg.GasFeeCap, msg.GasPremium)
+	}
+}
+
+func TestParseStrategy(t *testing.T) {
+	s, err := ParseStrategy("urgent")
+	if err != nil || s.Name != Urgent.Name {
+		t.Fatalf("expected urgent, got %v %v", s, err)
+	}
+	if _, err := ParseStrategy("fast"); err == nil {
+		t.Fatal("expected unknown strategy error")
+	}
+}
@@ -0,0 +1,192 @@
+package history
+
+import (
+	"context"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/exitcode"
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+	"sort"
+)
+
+const (
+	DirectionIn   = "in"
+	DirectionOut  = "out"
+	DirectionSelf = "self"
+)
+
+// Node is the part of the lotus full node api used to list the messages of an address,
+// api.FullNode satisfies it
+type Node interface {
+	ChainHead(context.Context) (*types.TipSet, error)
+	ChainGetTipSetByHeight(context.Context, abi.ChainEpoch, types.TipSetKey) (*types.TipSet, error)
+	ChainGetBlock(context.Context, cid.Cid) (*types.BlockHeader, error)
+	ChainGetMessage(context.Context, cid.Cid) (*types.Message, error)
+	StateListMessages(ctx context.Context, match *api.MessageMatch, tsk types.TipSetKey, toht abi.ChainEpoch) ([]cid.Cid, error)
+	StateSearchMsg(ctx context.Context, from types.TipSetKey, msg cid.Cid, limit abi.ChainEpoch, allowReplaced bool) (*api.MsgLookup, error)
+	StateLookupID(context.Context, address.Address, types.TipSetKey) (address.Address, error)
+	StateGetActor(ctx context.Context, actor address.Address, tsk types.TipSetKey) (*types.Actor, error)
+}
+
+// Record is one message of the address found on chain
+type Record struct {
+	Cid          cid.Cid
+	Height       abi.ChainEpoch
+	Direction    string
+	Counterparty address.Address
+	Message      *types.Message
+	ToCode       cid.Cid // cid.Undef when the receiver actor doesn't exist
+	ExitCode     exitcode.ExitCode
+	GasUsed      int64
+	Burned       abi.TokenAmount
+}
+
+// List returns the executed messages sent from or to addr between fromEpoch and toEpoch, oldest first
+func List(ctx context.Context, node Node, addr address.Address, fromEpoch, toEpoch abi.ChainEpoch) ([]Record, error) {
+	head, err := node.ChainHead(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ts := head
+	if toEpoch > 0 && toEpoch < head.Height() {
+		ts, err = node.ChainGetTipSetByHeight(ctx, toEpoch, head.Key())
+		if err != nil {
+			return nil, xerrors.Errorf("getting tipset at %d: %w", toEpoch, err)
+		}
+	}
+
+	idAddr, err := node.StateLookupID(ctx, addr, head.Key())
+	if err != nil {
+		return nil, xerrors.Errorf("looking up id of %s: %w", addr, err)
+	}
+
+	// payments to miners and multisigs are often addressed to the id address
+	matches := []*api.MessageMatch{{From: addr}, {To: addr}}
+	if idAddr != addr {
+		matches = append(matches, &api.MessageMatch{From: idAddr}, &api.MessageMatch{To: idAddr})
+	}
+
+	var cids []cid.Cid
+	seen := make(map[cid.Cid]struct{})
+	for _, match := range matches {
+		found, err := node.StateListMessages(ctx, match, ts.Key(), fromEpoch)
+		if err != nil {
+			return nil, xerrors.Errorf("listing messages: %w", err)
+		}
+
+		for _, c := range found {
+			if _, ok := seen[c]; ok {
+				continue
+			}
+			seen[c] = struct{}{}
+			cids = append(cids, c)
+		}
+	}
+
+	baseFees := make(map[types.TipSetKey]abi.TokenAmount)
+	codes := make(map[address.Address]cid.Cid)
+
+	var records []Record
+	for _, c := range cids {
+		msg, err := node.ChainGetMessage(ctx, c)
+		if err != nil {
+			return nil, xerrors.Errorf("getting message %s: %w", c, err)
+		}
+
+		lookup, err := node.StateSearchMsg(ctx, types.EmptyTSK, c, api.LookbackNoLimit, true)
+		if err != nil {
+			return nil, xerrors.Errorf("searching message %s: %w", c, err)
+		}
+		if lookup == nil {
+			continue
+		}
+
+		// the execution tipset carries the base fee the message paid as its parent base fee
+		baseFee, ok := baseFees[lookup.TipSet]
+		if !ok {
+			blk, err := node.ChainGetBlock(ctx, lookup.TipSet.Cids()[0])
+			if err != nil {
+				return nil, xerrors.Errorf("getting block of tipset %s: %w", lookup.TipSet, err)
+			}
+			baseFee = blk.ParentBaseFee
+			baseFees[lookup.TipSet] = baseFee
+		}
+
+		code, ok := codes[msg.To]
+		if !ok {
+			code = cid.Undef
+			if act, err := node.StateGetActor(ctx, msg.To, head.Key()); err == nil {
+				code = act.Code
+			}
+			codes[msg.To] = code
+		}
+
+		fromSelf := msg.From == addr || msg.From == idAddr
+		toSelf := msg.To == addr || msg.To == idAddr
+
+		r := Record{
+			Cid:       c,
+			Height:    lookup.Height,
+			Direction: DirectionIn,
+			Message:   msg,
+			ToCode:    code,
+			ExitCode:  lookup.Receipt.ExitCode,
+			GasUsed:   lookup.Receipt.GasUsed,
+			Burned:    Burned(lookup.Receipt.GasUsed, msg.GasLimit, baseFee, msg.GasFeeCap),
+		}
+		switch {
+		case fromSelf && toSelf:
+			r.Direction = DirectionSelf
+			r.Counterparty = msg.To
+		case fromSelf:
+			r.Direction = DirectionOut
+			r.Counterparty = msg.To
+		default:
+			r.Counterparty = msg.From
+		}
+
+		records = append(records, r)
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Height < records[j].Height
+	})
+
+	return records, nil
+}
+
+const (
+	gasOveruseNum   = 11
+	gasOveruseDenom = 10
+)
+
+// Burned returns the base fee burn plus the over estimation burn of an executed message,
+// following the gas outputs computed by the lotus vm
+func Burned(gasUsed, gasLimit int64, baseFee, feeCap abi.TokenAmount) abi.TokenAmount {
+	baseFeeToPay := baseFee
+	if baseFee.GreaterThan(feeCap) {
+		baseFeeToPay = feeCap
+	}
+
+	burn := big.Mul(baseFeeToPay, big.NewInt(gasUsed))
+
+	var gasBurned int64
+	if gasUsed == 0 {
+		gasBurned = gasLimit
+	} else {
+		over := gasLimit - (gasOveruseNum*gasUsed)/gasOveruseDenom
+		if over > gasUsed {
+			over = gasUsed
+		}
+		if over > 0 {
+			gasBurned = big.Div(big.Mul(big.NewInt(gasLimit-gasUsed), big.NewInt(over)), big.NewInt(gasUsed)).Int64()
+		}
+	}
+
+	return big.Add(burn, big.Mul(baseFeeToPay, big.NewInt(gasBurned)))
+}
@@ -0,0 +1,199 @@
+package history
+
+import (
+	"context"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/exitcode"
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+	"testing"
+)
+
+var (
+	self, _   = address.NewFromString("f1s6p5rqjg7msu6xoseyznniarazsyh5ukbned4yi")
+	selfID, _ = address.NewFromString("f01000")
+	other, _  = address.NewFromString("f1b2j6uc4mxxd5yqw2d7jgae4wsf3knvlwtuhinpy")
+)
+
+func testCid(t *testing.T, s string) cid.Cid {
+	c, err := cid.V1Builder{Codec: cid.DagCBOR, MhType: 0x12 /* sha2-256 */}.Sum([]byte(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// mockNode serves a fixed set of executed messages
+type mockNode struct {
+	head     *types.TipSet
+	blocks   map[cid.Cid]*types.BlockHeader
+	messages map[cid.Cid]*types.Message
+	lookups  map[cid.Cid]*api.MsgLookup
+}
+
+func (m *mockNode) ChainHead(context.Context) (*types.TipSet, error) {
+	return m.head, nil
+}
+
+func (m *mockNode) ChainGetTipSetByHeight(context.Context, abi.ChainEpoch, types.TipSetKey) (*types.TipSet, error) {
+	return m.head, nil
+}
+
+func (m *mockNode) ChainGetBlock(_ context.Context, c cid.Cid) (*types.BlockHeader, error) {
+	b, ok := m.blocks[c]
+	if !ok {
+		return nil, xerrors.Errorf("block %s not found", c)
+	}
+	return b, nil
+}
+
+func (m *mockNode) ChainGetMessage(_ context.Context, c cid.Cid) (*types.Message, error) {
+	msg, ok := m.messages[c]
+	if !ok {
+		return nil, xerrors.Errorf("message %s not found", c)
+	}
+	return msg, nil
+}
+
+func (m *mockNode) StateListMessages(_ context.Context, match *api.MessageMatch, _ types.TipSetKey, toht abi.ChainEpoch) ([]cid.Cid, error) {
+	var out []cid.Cid
+	for c, msg := range m.messages {
+		if m.lookups[c].Height < toht {
+			continue
+		}
+		if (match.From != address.Undef && msg.From == match.From) || (match.To != address.Undef && msg.To == match.To) {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockNode) StateSearchMsg(_ context.Context, _ types.TipSetKey, c cid.Cid, _ abi.ChainEpoch, _ bool) (*api.MsgLookup, error) {
+	return m.lookups[c], nil
+}
+
+func (m *mockNode) StateLookupID(context.Context, address.Address, types.TipSetKey) (address.Address, error) {
+	return selfID, nil
+}
+
+func (m *mockNode) StateGetActor(context.Context, address.Address, types.TipSetKey) (*types.Actor, error) {
+	return nil, xerrors.New("actor not found")
+}
+
+func newMockNode(t *testing.T) *mockNode {
+	miner, _ := address.NewIDAddress(1234)
+	blk := &types.BlockHeader{
+		Miner:                 miner,
+		Height:                100,
+		ParentStateRoot:       testCid(t, "state"),
+		ParentMessageReceipts: testCid(t, "receipts"),
+		Messages:              testCid(t, "messages"),
+		ParentBaseFee:         abi.NewTokenAmount(100),
+	}
+	head, err := types.NewTipSet([]*types.BlockHeader{blk})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n := &mockNode{
+		head:     head,
+		blocks:   map[cid.Cid]*types.BlockHeader{blk.Cid(): blk},
+		messages: make(map[cid.Cid]*types.Message),
+		lookups:  make(map[cid.Cid]*api.MsgLookup),
+	}
+
+	add := func(name string, from, to address.Address, height abi.ChainEpoch, code exitcode.ExitCode) {
+		c := testCid(t, name)
+		n.messages[c] = &types.Message{
+			From:      from,
+			To:        to,
+			Value:     types.FromFil(1),
+			GasLimit:  1000,
+			GasFeeCap: abi.NewTokenAmount(200),
+		}
+		n.lookups[c] = &api.MsgLookup{
+			Message: c,
+			Receipt: types.MessageReceipt{ExitCode: code, GasUsed: 1000},
+			TipSet:  head.Key(),
+			Height:  height,
+		}
+	}
+	add("out", self, other, 90, exitcode.Ok)
+	add("in", other, self, 80, exitcode.Ok)
+	add("failed", self, other, 95, exitcode.ErrInsufficientFunds)
+	add("in-id", other, selfID, 85, exitcode.Ok)
+	add("self-id", selfID, self, 97, exitcode.Ok)
+	add("old", other, self, 10, exitcode.Ok)
+
+	return n
+}
+
+func TestList(t *testing.T) {
+	records, err := List(context.Background(), newMockNode(t), self, 50, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the messages to and from the id address are found too, the self message matches twice but is listed once
+	if len(records) != 5 {
+		t.Fatalf("expected 5 records, got %d", len(records))
+	}
+
+	expected := []struct {
+		height       abi.ChainEpoch
+		direction    string
+		code         exitcode.ExitCode
+		counterparty address.Address
+	}{
+		{80, DirectionIn, exitcode.Ok, other},
+		{85, DirectionIn, exitcode.Ok, other},
+		{90, DirectionOut, exitcode.Ok, other},
+		{95, DirectionOut, exitcode.ErrInsufficientFunds, other},
+		{97, DirectionSelf, exitcode.Ok, self},
+	}
+	for i, e := range expected {
+		r := records[i]
+		if r.Height != e.height || r.Direction != e.direction || r.ExitCode != e.code {
+			t.Fatalf("record %d: expected %d %s %d, got %d %s %d", i, e.height, e.direction, e.code, r.Height, r.Direction, r.ExitCode)
+		}
+		if r.Counterparty != e.counterparty {
+			t.Fatalf("record %d: expected counterparty %s, got %s", i, e.counterparty, r.Counterparty)
+		}
+		if !r.Burned.Equals(abi.NewTokenAmount(100 * 1000)) {
+			t.Fatalf("record %d: expected 100000 burned, got %s", i, r.Burned)
+		}
+		if r.ToCode != cid.Undef {
+			t.Fatalf("record %d: expected undefined receiver code", i)
+		}
+	}
+}
+
+func TestBurned(t *testing.T) {
+	baseFee := abi.NewTokenAmount(100)
+	feeCap := abi.NewTokenAmount(200)
+
+	cases := []struct {
+		gasUsed, gasLimit int64
+		expected          int64
+	}{
+		{1000, 1000, 100 * 1000},
+		{1000, 1100, 100 * 1000},           // within the 10% overuse allowance
+		{1000, 2000, 100 * (1000 + 900)},   // (2000-1000) * (2000-1100) / 1000 burned
+		{1000, 10000, 100 * (1000 + 9000)}, // over estimation is capped at gas used, all unused gas is burned
+		{0, 1000, 100 * 1000},
+	}
+	for _, c := range cases {
+		got := Burned(c.gasUsed, c.gasLimit, baseFee, feeCap)
+		if !got.Equals(big.NewInt(c.expected)) {
+			t.Fatalf("used %d limit %d: expected %d, got %s", c.gasUsed, c.gasLimit, c.expected, got)
+		}
+	}
+
+	if got := Burned(1000, 1000, abi.NewTokenAmount(300), feeCap); !got.Equals(big.NewInt(200 * 1000)) {
+		t.Fatalf("base fee above fee cap should burn at the fee cap, got %s", got)
+	}
+}
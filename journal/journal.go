@@ -0,0 +1,155 @@
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/exitcode"
+	"github.com/filecoin-project/lotus/chain/types"
+	"golang.org/x/xerrors"
+	"os"
+	"time"
+)
+
+const (
+	StatusPending = "pending"
+	StatusOk      = "ok"
+	StatusFailed  = "failed"
+)
+
+// Entry is one line of the journal. A sent message is recorded with its unsigned message,
+// later status updates are appended as entries with the same Cid and no message.
+type Entry struct {
+	Time     time.Time
+	Cid      string
+	Message  *types.Message `json:",omitempty"`
+	Command  string         `json:",omitempty"`
+	Label    string         `json:",omitempty"`
+	Memo     string         `json:",omitempty"`
+	Status   string
+	ExitCode exitcode.ExitCode `json:",omitempty"`
+	Height   int64             `json:",omitempty"`
+}
+
+// Journal is an append-only JSON lines file of the messages sent by the wallet
+type Journal struct {
+	path string
+}
+
+func New(path string) *Journal {
+	return &Journal{path: path}
+}
+
+func (j *Journal) Append(e Entry) error {
+	f, err := os.OpenFile(j.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return xerrors.Errorf("opening journal %s: %w", j.path, err)
+	}
+	defer f.Close()
+
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		return xerrors.Errorf("writing journal %s: %w", j.path, err)
+	}
+
+	return nil
+}
+
+// Sent records a pushed message as pending
+func (j *Journal) Sent(msgCid string, msg *types.Message, command, label, memo string) error {
+	return j.Append(Entry{
+		Time:    time.Now(),
+		Cid:     msgCid,
+		Message: msg,
+		Command: command,
+		Label:   label,
+		Memo:    memo,
+		Status:  StatusPending,
+	})
+}
+
+// Confirmed records the receipt of a message found on chain
+func (j *Journal) Confirmed(msgCid string, code exitcode.ExitCode, height int64) error {
+	status := StatusOk
+	if code != exitcode.Ok {
+		status = StatusFailed
+	}
+
+	return j.Append(Entry{
+		Time:     time.Now(),
+		Cid:      msgCid,
+		Status:   status,
+		ExitCode: code,
+		Height:   height,
+	})
+}
+
+// Entries returns the sent messages in the order they were sent, with their latest status
+func (j *Journal) Entries() ([]Entry, error) {
+	f, err := os.Open(j.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, xerrors.Errorf("opening journal %s: %w", j.path, err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	index := make(map[string]int)
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, xerrors.Errorf("parsing journal %s: %w", j.path, err)
+		}
+
+		i, ok := index[e.Cid]
+		if !ok {
+			index[e.Cid] = len(entries)
+			entries = append(entries, e)
+			continue
+		}
+
+		if e.Message == nil {
+			entries[i].Status = e.Status
+			entries[i].ExitCode = e.ExitCode
+			entries[i].Height = e.Height
+		}
+	}
+
+	return entries, scanner.Err()
+}
+
+// NextNonce returns the nonce after the highest pending message sent from the address since the time,
+// false when the address has no such message. Pending messages below the on-chain nonce of the address
+// were executed or replaced, older ones were probably dropped, neither may hold the nonce back.
+func (j *Journal) NextNonce(from address.Address, chainNonce uint64, since time.Time) (uint64, bool, error) {
+	entries, err := j.Entries()
+	if err != nil {
+		return 0, false, err
+	}
+
+	var next uint64
+	var found bool
+	for _, e := range entries {
+		if e.Status != StatusPending || e.Message == nil || e.Message.From != from {
+			continue
+		}
+		if e.Message.Nonce < chainNonce || e.Time.Before(since) {
+			continue
+		}
+		if !found || e.Message.Nonce+1 > next {
+			next = e.Message.Nonce + 1
+			found = true
+		}
+	}
+
+	return next, found, nil
+}
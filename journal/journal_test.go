@@ -0,0 +1,101 @@
+package journal
+
+import (
+	"fmt"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/exitcode"
+	"github.com/filecoin-project/lotus/chain/types"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestJournalEntries(t *testing.T) {
+	j := New(filepath.Join(t.TempDir(), "journal.jsonl"))
+
+	from, _ := address.NewFromString("f1s6p5rqjg7msu6xoseyznniarazsyh5ukbned4yi")
+	to, _ := address.NewFromString("f1b2j6uc4mxxd5yqw2d7jgae4wsf3knvlwtuhinpy")
+	msg := &types.Message{From: from, To: to, Value: types.FromFil(1), Nonce: 7}
+
+	if err := j.Sent("cid1", msg, "fil-wallet wallet send", "ops", "rent"); err != nil {
+		t.Fatal(err)
+	}
+	if err := j.Sent("cid2", msg, "fil-wallet wallet send", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := j.Confirmed("cid1", exitcode.Ok, 100); err != nil {
+		t.Fatal(err)
+	}
+	if err := j.Confirmed("cid2", exitcode.ErrInsufficientFunds, 101); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := j.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+
+	if entries[0].Cid != "cid1" || entries[0].Status != StatusOk || entries[0].Height != 100 || entries[0].Memo != "rent" {
+		t.Fatalf("unexpected first entry: %+v", entries[0])
+	}
+
+	if entries[1].Status != StatusFailed || entries[1].ExitCode != exitcode.ErrInsufficientFunds {
+		t.Fatalf("unexpected second entry: %+v", entries[1])
+	}
+
+	if entries[1].Message == nil || entries[1].Message.Nonce != 7 {
+		t.Fatalf("message not recorded: %+v", entries[1])
+	}
+}
+
+func TestJournalNextNonce(t *testing.T) {
+	j := New(filepath.Join(t.TempDir(), "journal.jsonl"))
+
+	from, _ := address.NewFromString("f1s6p5rqjg7msu6xoseyznniarazsyh5ukbned4yi")
+	to, _ := address.NewFromString("f1b2j6uc4mxxd5yqw2d7jgae4wsf3knvlwtuhinpy")
+
+	since := time.Now().Add(-time.Hour)
+	if _, found, err := j.NextNonce(from, 0, since); err != nil || found {
+		t.Fatalf("expected no pending message in an empty journal, got %v %v", found, err)
+	}
+
+	for i, nonce := range []uint64{3, 5, 4} {
+		msg := &types.Message{From: from, To: to, Value: types.FromFil(1), Nonce: nonce}
+		if err := j.Sent(fmt.Sprintf("cid%d", i), msg, "", "", ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := j.Sent("other", &types.Message{From: to, To: from, Value: types.FromFil(1), Nonce: 9}, "", "", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	next, found, err := j.NextNonce(from, 0, since)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !found || next != 6 {
+		t.Fatalf("expected next nonce 6, got %d %v", next, found)
+	}
+
+	// confirmed messages are left to the node
+	if err := j.Confirmed("cid1", exitcode.Ok, 100); err != nil {
+		t.Fatal(err)
+	}
+	if next, _, _ = j.NextNonce(from, 0, since); next != 5 {
+		t.Fatalf("expected next nonce 5 after confirmation, got %d", next)
+	}
+
+	// pending messages below the on-chain nonce were executed or replaced without being confirmed
+	if _, found, _ = j.NextNonce(from, 5, since); found {
+		t.Fatal("expected the messages below the on-chain nonce to be ignored")
+	}
+
+	// a pending message that was never confirmed expires
+	if _, found, _ = j.NextNonce(from, 0, time.Now().Add(time.Minute)); found {
+		t.Fatal("expected the old pending messages to be ignored")
+	}
+}
@@ -21,6 +21,7 @@ func main() {
 		Commands: []*cli.Command{
 			wallet.Cmd,
 			wallet.ChainCmd,
+			wallet.ServeCmd,
 		},
 	}
 
@@ -0,0 +1,146 @@
+package mnemonic
+
+import (
+	"fmt"
+	"github.com/tyler-smith/go-bip39"
+	"golang.org/x/xerrors"
+	"sort"
+	"strings"
+)
+
+// Normalize lowercases the words and joins them with single spaces, the form the seed is derived from
+func Normalize(m string) string {
+	return strings.Join(strings.Fields(strings.ToLower(m)), " ")
+}
+
+// Validate checks the word count, the words and the checksum of the mnemonic, unknown words
+// are reported with the closest words of the word list
+func Validate(m string) error {
+	words := strings.Fields(Normalize(m))
+	if !validCount(len(words)) {
+		return xerrors.Errorf("invalid mnemonic: %d words, must be 12 / 15 / 18 / 21 / 24", len(words))
+	}
+
+	var unknown []string
+	for i, w := range words {
+		if _, ok := bip39.GetWordIndex(w); !ok {
+			unknown = append(unknown, fmt.Sprintf("word %d %q is not in the word list, did you mean %s?", i+1, w, strings.Join(Suggest(w, 3), " / ")))
+		}
+	}
+	if len(unknown) > 0 {
+		return xerrors.Errorf("invalid mnemonic: %s", strings.Join(unknown, "; "))
+	}
+
+	if _, err := bip39.EntropyFromMnemonic(strings.Join(words, " ")); err != nil {
+		return xerrors.Errorf("invalid mnemonic: checksum mismatch, a word is probably mistyped or out of order, try `wallet recover`")
+	}
+
+	return nil
+}
+
+// Suggest returns the n words of the word list closest to the word by edit distance
+func Suggest(word string, n int) []string {
+	wordList := bip39.GetWordList()
+
+	type match struct {
+		word string
+		dist int
+	}
+	matches := make([]match, 0, len(wordList))
+	for _, w := range wordList {
+		matches = append(matches, match{w, distance(word, w)})
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].dist < matches[j].dist
+	})
+
+	if n > len(matches) {
+		n = len(matches)
+	}
+	out := make([]string, n)
+	for i := range out {
+		out[i] = matches[i].word
+	}
+	return out
+}
+
+// Candidates returns the mnemonics with a valid checksum that differ from m by a single word. When m is
+// a word short, the candidates complete it with a final word instead. Positions with unknown words are
+// the only ones replaced when there are any, replacements closer to the original word come first.
+func Candidates(m string) ([]string, error) {
+	words := strings.Fields(Normalize(m))
+
+	switch {
+	case validCount(len(words) + 1):
+		var out []string
+		for _, w := range bip39.GetWordList() {
+			c := strings.Join(append(words[:len(words):len(words)], w), " ")
+			if bip39.IsMnemonicValid(c) {
+				out = append(out, c)
+			}
+		}
+		return out, nil
+	case validCount(len(words)):
+	default:
+		return nil, xerrors.Errorf("%d words, must be 12 / 15 / 18 / 21 / 24 or a word short of it", len(words))
+	}
+
+	var positions []int
+	for i, w := range words {
+		if _, ok := bip39.GetWordIndex(w); !ok {
+			positions = append(positions, i)
+		}
+	}
+	if len(positions) > 1 {
+		return nil, xerrors.Errorf("%d words are not in the word list, only one can be recovered", len(positions))
+	}
+	if len(positions) == 0 {
+		for i := range words {
+			positions = append(positions, i)
+		}
+	}
+
+	var out []string
+	for _, i := range positions {
+		for _, w := range Suggest(words[i], len(bip39.GetWordList())) {
+			if w == words[i] {
+				continue
+			}
+
+			c := append([]string{}, words...)
+			c[i] = w
+			if s := strings.Join(c, " "); bip39.IsMnemonicValid(s) {
+				out = append(out, s)
+			}
+		}
+	}
+
+	return out, nil
+}
+
+func validCount(n int) bool {
+	return n%3 == 0 && n >= 12 && n <= 24
+}
+
+// distance is the levenshtein distance of a and b
+func distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev[len(b)]
+}
@@ -0,0 +1,65 @@
+package mnemonic
+
+import (
+	"strings"
+	"testing"
+)
+
+const valid = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+func TestValidate(t *testing.T) {
+	if err := Validate(valid); err != nil {
+		t.Fatal(err)
+	}
+	if err := Validate("  Abandon " + strings.ToUpper(valid[8:]) + " "); err != nil {
+		t.Fatalf("expected case and spacing to be normalized: %s", err)
+	}
+
+	err := Validate(strings.Replace(valid, "about", "abuot", 1))
+	if err == nil || !strings.Contains(err.Error(), `word 12 "abuot"`) || !strings.Contains(err.Error(), "about") {
+		t.Fatalf("expected the unknown word to be reported with suggestions, got %v", err)
+	}
+
+	if err := Validate(strings.Replace(valid, "about", "above", 1)); err == nil || !strings.Contains(err.Error(), "checksum") {
+		t.Fatalf("expected a checksum error, got %v", err)
+	}
+
+	if err := Validate("abandon about"); err == nil {
+		t.Fatal("expected the word count to be refused")
+	}
+}
+
+func TestCandidates(t *testing.T) {
+	contains := func(cs []string, m string) bool {
+		for _, c := range cs {
+			if c == m {
+				return true
+			}
+		}
+		return false
+	}
+
+	cs, err := Candidates(strings.Replace(valid, "about", "abuot", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cs) == 0 || cs[0] != valid {
+		t.Fatalf("expected the closest candidate first, got %d candidates", len(cs))
+	}
+
+	cs, err = Candidates(strings.Replace(valid, "abandon abandon abandon about", "abandon abandon ability about", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !contains(cs, valid) {
+		t.Fatal("expected the substitution to be found")
+	}
+
+	cs, err = Candidates(strings.TrimSuffix(valid, " about"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !contains(cs, valid) || len(cs) != 128 {
+		t.Fatalf("expected 128 final words including about, got %d", len(cs))
+	}
+}
@@ -0,0 +1,77 @@
+package policy
+
+import (
+	"bufio"
+	"encoding/json"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"golang.org/x/xerrors"
+	"os"
+	"time"
+)
+
+// Entry is one outgoing message recorded in the ledger
+type Entry struct {
+	Time  time.Time
+	From  address.Address
+	To    address.Address
+	Value abi.TokenAmount
+	Cid   string
+}
+
+// Ledger is an append-only JSON lines file of outgoing messages, used to track outflow
+type Ledger struct {
+	path string
+}
+
+func NewLedger(path string) *Ledger {
+	return &Ledger{path: path}
+}
+
+func (l *Ledger) Record(e Entry) error {
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return xerrors.Errorf("opening ledger %s: %w", l.path, err)
+	}
+	defer f.Close()
+
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		return xerrors.Errorf("writing ledger %s: %w", l.path, err)
+	}
+
+	return nil
+}
+
+// Outflow sums the value sent from the address since the given time
+func (l *Ledger) Outflow(from address.Address, since time.Time) (abi.TokenAmount, error) {
+	total := big.Zero()
+
+	f, err := os.Open(l.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return total, nil
+		}
+		return total, xerrors.Errorf("opening ledger %s: %w", l.path, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return total, xerrors.Errorf("parsing ledger %s: %w", l.path, err)
+		}
+
+		if e.From == from && !e.Time.Before(since) {
+			total = big.Add(total, e.Value)
+		}
+	}
+
+	return total, scanner.Err()
+}
@@ -0,0 +1,351 @@
+package policy
+
+import (
+	"fmt"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/spf13/viper"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/xerrors"
+	"strings"
+)
+
+// Rule limits the messages sent from an address, empty fields are not limited
+type Rule struct {
+	MaxValue     string   `yaml:"maxValue"`
+	DailyOutflow string   `yaml:"dailyOutflow"`
+	MaxFeeCap    string   `yaml:"maxFeeCap"`
+	Allow        []string `yaml:"allow"`
+	Deny         []string `yaml:"deny"`
+	// Sign restricts the content of the messages, every message must match one of the sign rules when set
+	Sign []SignRule `yaml:"sign"`
+	// SignTypes are the signing requests other than chain messages the remote signer accepts, ps: block,
+	// none by default
+	SignTypes []string `yaml:"signTypes"`
+}
+
+// SignRule matches messages to one of the destinations calling one of the methods, empty fields match anything
+type SignRule struct {
+	To []string `yaml:"to"`
+	// Methods are method names from the actor registry, ps: Send, SubmitWindowedPoSt, or method numbers
+	Methods  []string `yaml:"methods"`
+	MaxValue string   `yaml:"maxValue"`
+}
+
+// Policy is the spending policy file, rules in Addresses override the Default rule field by field
+type Policy struct {
+	OverridePassword string          `yaml:"overridePassword"`
+	Default          Rule            `yaml:"default"`
+	Addresses        map[string]Rule `yaml:"addresses"`
+
+	resolve Resolver
+	ids     map[address.Address]address.Address
+}
+
+// Resolver returns the id address of an address, so the destinations of the rules match the id and the
+// robust address of the same actor
+type Resolver func(address.Address) (address.Address, error)
+
+// SetResolver resolves the destinations to id addresses before they are compared, an address that can't
+// be resolved, e.g. an account not on chain yet, is compared as it is
+func (p *Policy) SetResolver(r Resolver) {
+	p.resolve = r
+	p.ids = make(map[address.Address]address.Address)
+}
+
+type limits struct {
+	maxValue     *abi.TokenAmount
+	dailyOutflow *abi.TokenAmount
+	maxFeeCap    *abi.TokenAmount
+	allow        []address.Address
+	deny         []address.Address
+}
+
+func Load(path string) (*Policy, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetConfigType("yaml")
+
+	if err := v.ReadInConfig(); err != nil {
+		return nil, xerrors.Errorf("reading policy %s: %w", path, err)
+	}
+
+	var p Policy
+	if err := v.Unmarshal(&p); err != nil {
+		return nil, xerrors.Errorf("parsing policy %s: %w", path, err)
+	}
+
+	if err := p.Validate(); err != nil {
+		return nil, xerrors.Errorf("invalid policy %s: %w", path, err)
+	}
+
+	return &p, nil
+}
+
+// Validate makes sure every amount and address in the policy can be parsed
+func (p *Policy) Validate() error {
+	if _, err := p.Default.limits(); err != nil {
+		return xerrors.Errorf("default: %w", err)
+	}
+
+	for a, r := range p.Addresses {
+		if _, err := address.NewFromString(a); err != nil {
+			return xerrors.Errorf("parsing address %s: %w", a, err)
+		}
+		if _, err := r.limits(); err != nil {
+			return xerrors.Errorf("%s: %w", a, err)
+		}
+	}
+
+	return nil
+}
+
+// Rule returns the rule for the from address, merged with the default rule
+func (p *Policy) Rule(from address.Address) Rule {
+	r := p.Default
+	for a, ar := range p.Addresses {
+		addr, err := address.NewFromString(a)
+		if err != nil || addr != from {
+			continue
+		}
+
+		if ar.MaxValue != "" {
+			r.MaxValue = ar.MaxValue
+		}
+		if ar.DailyOutflow != "" {
+			r.DailyOutflow = ar.DailyOutflow
+		}
+		if ar.MaxFeeCap != "" {
+			r.MaxFeeCap = ar.MaxFeeCap
+		}
+		if ar.Allow != nil {
+			r.Allow = ar.Allow
+		}
+		if ar.Deny != nil {
+			r.Deny = ar.Deny
+		}
+		if ar.Sign != nil {
+			r.Sign = ar.Sign
+		}
+		if ar.SignTypes != nil {
+			r.SignTypes = ar.SignTypes
+		}
+	}
+
+	return r
+}
+
+// Check evaluates the message against the rule of its sender, spent is the outflow of
+// the sender in the last 24 hours. All violations are returned in one error.
+func (p *Policy) Check(msg *types.Message, spent abi.TokenAmount) error {
+	l, err := p.Rule(msg.From).limits()
+	if err != nil {
+		return err
+	}
+
+	var violations []string
+	if l.maxValue != nil && msg.Value.GreaterThan(*l.maxValue) {
+		violations = append(violations, fmt.Sprintf("value %s exceeds max value %s", types.FIL(msg.Value), types.FIL(*l.maxValue)))
+	}
+
+	if l.dailyOutflow != nil {
+		total := big.Add(spent, msg.Value)
+		if total.GreaterThan(*l.dailyOutflow) {
+			violations = append(violations, fmt.Sprintf("daily outflow %s (spent %s) exceeds cap %s", types.FIL(total), types.FIL(spent), types.FIL(*l.dailyOutflow)))
+		}
+	}
+
+	if l.maxFeeCap != nil && msg.GasFeeCap.GreaterThan(*l.maxFeeCap) {
+		violations = append(violations, fmt.Sprintf("gas fee cap %s exceeds ceiling %s", msg.GasFeeCap, *l.maxFeeCap))
+	}
+
+	if len(l.allow) > 0 && !p.contains(l.allow, msg.To) {
+		violations = append(violations, fmt.Sprintf("destination %s is not in the allowlist", msg.To))
+	}
+
+	if p.contains(l.deny, msg.To) {
+		violations = append(violations, fmt.Sprintf("destination %s is in the denylist", msg.To))
+	}
+
+	if len(violations) > 0 {
+		return xerrors.Errorf("policy violation: %s", strings.Join(violations, "; "))
+	}
+
+	return nil
+}
+
+// CheckSign evaluates the message against the sign rules of its sender, method is the
+// method name of the message resolved with the actor registry
+func (p *Policy) CheckSign(msg *types.Message, method string) error {
+	r := p.Rule(msg.From)
+	if len(r.Sign) == 0 {
+		return nil
+	}
+
+	for _, sr := range r.Sign {
+		ok, err := p.matches(sr, msg, method)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+	}
+
+	return xerrors.Errorf("policy violation: %s(%d) to %s with value %s matches no sign rule of %s", method, msg.Method, msg.To, types.FIL(msg.Value), msg.From)
+}
+
+// CheckSignType refuses the signing requests other than chain messages, unless their type is listed in
+// the sign types of the address. Only chain messages go through the rules of the policy.
+func (p *Policy) CheckSignType(from address.Address, typ string) error {
+	for _, t := range p.Rule(from).SignTypes {
+		if t == typ {
+			return nil
+		}
+	}
+
+	return xerrors.Errorf("policy violation: %s may only sign chain messages, not %s, unless signTypes allows it", from, typ)
+}
+
+func (p *Policy) matches(sr SignRule, msg *types.Message, method string) (bool, error) {
+	if len(sr.To) > 0 {
+		to, err := parseAddresses(sr.To)
+		if err != nil {
+			return false, err
+		}
+		if !p.contains(to, msg.To) {
+			return false, nil
+		}
+	}
+
+	if len(sr.Methods) > 0 {
+		found := false
+		for _, m := range sr.Methods {
+			if m == method || m == fmt.Sprint(uint64(msg.Method)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false, nil
+		}
+	}
+
+	maxValue, err := parseAmount(sr.MaxValue)
+	if err != nil {
+		return false, err
+	}
+	if maxValue != nil && msg.Value.GreaterThan(*maxValue) {
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// VerifyOverride checks the override password against the bcrypt hash in the policy
+func (p *Policy) VerifyOverride(password string) error {
+	if p.OverridePassword == "" {
+		return xerrors.New("policy has no override password, it can't be overridden")
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(p.OverridePassword), []byte(password)); err != nil {
+		return xerrors.New("wrong override password")
+	}
+
+	return nil
+}
+
+func HashPassword(password string) (string, error) {
+	h, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(h), nil
+}
+
+func (r Rule) limits() (*limits, error) {
+	var l limits
+	var err error
+	if l.maxValue, err = parseAmount(r.MaxValue); err != nil {
+		return nil, xerrors.Errorf("maxValue: %w", err)
+	}
+	if l.dailyOutflow, err = parseAmount(r.DailyOutflow); err != nil {
+		return nil, xerrors.Errorf("dailyOutflow: %w", err)
+	}
+	if l.maxFeeCap, err = parseAmount(r.MaxFeeCap); err != nil {
+		return nil, xerrors.Errorf("maxFeeCap: %w", err)
+	}
+	if l.allow, err = parseAddresses(r.Allow); err != nil {
+		return nil, xerrors.Errorf("allow: %w", err)
+	}
+	if l.deny, err = parseAddresses(r.Deny); err != nil {
+		return nil, xerrors.Errorf("deny: %w", err)
+	}
+	for i, sr := range r.Sign {
+		if _, err := parseAddresses(sr.To); err != nil {
+			return nil, xerrors.Errorf("sign %d to: %w", i, err)
+		}
+		if _, err := parseAmount(sr.MaxValue); err != nil {
+			return nil, xerrors.Errorf("sign %d maxValue: %w", i, err)
+		}
+	}
+
+	return &l, nil
+}
+
+func parseAmount(s string) (*abi.TokenAmount, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	f, err := types.ParseFIL(s)
+	if err != nil {
+		return nil, err
+	}
+
+	amt := abi.TokenAmount(f)
+	return &amt, nil
+}
+
+func parseAddresses(ss []string) ([]address.Address, error) {
+	var addrs []address.Address
+	for _, s := range ss {
+		addr, err := address.NewFromString(s)
+		if err != nil {
+			return nil, err
+		}
+		addrs = append(addrs, addr)
+	}
+
+	return addrs, nil
+}
+
+func (p *Policy) contains(addrs []address.Address, addr address.Address) bool {
+	id := p.id(addr)
+	for _, a := range addrs {
+		if a == addr || p.id(a) == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *Policy) id(addr address.Address) address.Address {
+	if p.resolve == nil || addr.Protocol() == address.ID {
+		return addr
+	}
+	if id, ok := p.ids[addr]; ok {
+		return id
+	}
+
+	id, err := p.resolve(addr)
+	if err != nil {
+		id = addr
+	}
+	p.ids[addr] = id
+	return id
+}
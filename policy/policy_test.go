@@ -0,0 +1,206 @@
+package policy
+
+import (
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/lotus/chain/types"
+	"golang.org/x/xerrors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var (
+	from, _    = address.NewFromString("f1s6p5rqjg7msu6xoseyznniarazsyh5ukbned4yi")
+	to, _      = address.NewFromString("f1b2j6uc4mxxd5yqw2d7jgae4wsf3knvlwtuhinpy")
+	blocked, _ = address.NewFromString("f1ys7n5mrm2vtx6coxc5wkmkddan7rznfkax3a6ki")
+)
+
+func testPolicy(t *testing.T) *Policy {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	err := os.WriteFile(path, []byte(`
+default:
+  maxValue: 10
+  dailyOutflow: 15
+  maxFeeCap: 1000attoFIL
+  deny:
+    - `+blocked.String()+`
+addresses:
+  `+from.String()+`:
+    maxValue: 20
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return p
+}
+
+func testMsg(to address.Address, fil int64, feeCap int64) *types.Message {
+	return &types.Message{
+		From:      from,
+		To:        to,
+		Value:     types.FromFil(uint64(fil)),
+		GasFeeCap: abi.NewTokenAmount(feeCap),
+	}
+}
+
+func TestPolicyCheck(t *testing.T) {
+	p := testPolicy(t)
+
+	if err := p.Check(testMsg(to, 12, 100), big.Zero()); err != nil {
+		t.Fatalf("address rule should allow 12 FIL: %s", err)
+	}
+
+	if err := p.Check(testMsg(to, 21, 100), big.Zero()); err == nil {
+		t.Fatal("expected max value violation")
+	}
+
+	if err := p.Check(testMsg(to, 10, 100), types.FromFil(10)); err == nil {
+		t.Fatal("expected daily outflow violation")
+	}
+
+	if err := p.Check(testMsg(to, 1, 1001), big.Zero()); err == nil {
+		t.Fatal("expected fee cap violation")
+	}
+
+	if err := p.Check(testMsg(blocked, 1, 100), big.Zero()); err == nil {
+		t.Fatal("expected denylist violation")
+	}
+}
+
+func TestPolicyCheckIDAddress(t *testing.T) {
+	p := testPolicy(t)
+	blockedID, _ := address.NewFromString("f01000")
+	toID, _ := address.NewFromString("f01001")
+	ids := map[address.Address]address.Address{blocked: blockedID, to: toID}
+	p.SetResolver(func(addr address.Address) (address.Address, error) {
+		if id, ok := ids[addr]; ok {
+			return id, nil
+		}
+		return address.Undef, xerrors.Errorf("actor %s not found", addr)
+	})
+
+	if err := p.Check(testMsg(blockedID, 1, 100), big.Zero()); err == nil {
+		t.Fatal("expected denylist violation for the id address of a denied destination")
+	}
+
+	p.Default.Allow = []string{to.String()}
+	if err := p.Check(testMsg(toID, 1, 100), big.Zero()); err != nil {
+		t.Fatalf("expected the id address of an allowed destination to be allowed: %s", err)
+	}
+
+	other, _ := address.NewFromString("f01002")
+	if err := p.Check(testMsg(other, 1, 100), big.Zero()); err == nil {
+		t.Fatal("expected allowlist violation")
+	}
+}
+
+func TestPolicyOverride(t *testing.T) {
+	p := testPolicy(t)
+	if err := p.VerifyOverride("secret"); err == nil {
+		t.Fatal("policy without override password must not be overridden")
+	}
+
+	h, err := HashPassword("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.OverridePassword = h
+
+	if err := p.VerifyOverride("wrong"); err == nil {
+		t.Fatal("expected wrong password")
+	}
+	if err := p.VerifyOverride("secret"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPolicyCheckSign(t *testing.T) {
+	miner, _ := address.NewFromString("f01234")
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	err := os.WriteFile(path, []byte(`
+addresses:
+  `+from.String()+`:
+    sign:
+      - to: [`+miner.String()+`]
+        methods: [SubmitWindowedPoSt, 7]
+        maxValue: 0
+    signTypes: [block]
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	post := &types.Message{From: from, To: miner, Value: big.Zero(), Method: 5}
+	if err := p.CheckSign(post, "SubmitWindowedPoSt"); err != nil {
+		t.Fatalf("expected window post to be allowed: %s", err)
+	}
+
+	proveCommit := &types.Message{From: from, To: miner, Value: big.Zero(), Method: 7}
+	if err := p.CheckSign(proveCommit, "ProveCommitSector"); err != nil {
+		t.Fatalf("expected method 7 to be allowed by number: %s", err)
+	}
+
+	if err := p.CheckSign(&types.Message{From: from, To: to, Value: types.FromFil(1)}, "Send"); err == nil {
+		t.Fatal("expected a plain send to be refused")
+	}
+
+	if err := p.CheckSign(&types.Message{From: from, To: miner, Value: types.FromFil(1), Method: 5}, "SubmitWindowedPoSt"); err == nil {
+		t.Fatal("expected a window post with value to be refused")
+	}
+
+	if err := p.CheckSign(&types.Message{From: to, To: to, Value: types.FromFil(1)}, "Send"); err != nil {
+		t.Fatalf("expected an address without sign rules to be unrestricted: %s", err)
+	}
+
+	if err := p.CheckSignType(from, "block"); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.CheckSignType(from, "unknown"); err == nil {
+		t.Fatal("expected raw signing to be refused")
+	}
+	if err := p.CheckSignType(to, "unknown"); err == nil {
+		t.Fatal("expected raw signing to be refused for an address without sign rules")
+	}
+	if err := p.CheckSignType(to, "block"); err == nil {
+		t.Fatal("expected block signing to be refused unless signTypes allows it")
+	}
+}
+
+func TestLedgerOutflow(t *testing.T) {
+	l := NewLedger(filepath.Join(t.TempDir(), "ledger.jsonl"))
+
+	now := time.Now()
+	entries := []Entry{
+		{Time: now.Add(-48 * time.Hour), From: from, To: to, Value: types.FromFil(5)},
+		{Time: now.Add(-time.Hour), From: from, To: to, Value: types.FromFil(3)},
+		{Time: now, From: to, To: from, Value: types.FromFil(7)},
+	}
+	for _, e := range entries {
+		if err := l.Record(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	spent, err := l.Outflow(from, now.Add(-24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !spent.Equals(types.FromFil(3)) {
+		t.Fatalf("expected 3 FIL outflow, got %s", types.FIL(spent))
+	}
+}
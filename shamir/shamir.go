@@ -0,0 +1,215 @@
+package shamir
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"github.com/tyler-smith/go-bip39"
+	"golang.org/x/xerrors"
+	"strings"
+)
+
+// A share is encoded as x | threshold | y bytes | checksum, the checksum is the first byte of
+// the sha256 of the rest and catches mistyped words.
+const shareOverhead = 3
+
+// exp and log tables of GF(2^8) with the AES polynomial x^8 + x^4 + x^3 + x + 1, generator 3
+var exp, log [256]byte
+
+func init() {
+	x := byte(1)
+	for i := 0; i < 255; i++ {
+		exp[i] = x
+		log[x] = byte(i)
+		// x *= 3
+		x ^= mulNoTable(x, 2)
+	}
+	exp[255] = exp[0]
+}
+
+func mulNoTable(a, b byte) byte {
+	var p byte
+	for b > 0 {
+		if b&1 == 1 {
+			p ^= a
+		}
+		hi := a & 0x80
+		a <<= 1
+		if hi != 0 {
+			a ^= 0x1b
+		}
+		b >>= 1
+	}
+	return p
+}
+
+func mul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return exp[(int(log[a])+int(log[b]))%255]
+}
+
+func div(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return exp[(int(log[a])-int(log[b])+255)%255]
+}
+
+// Split splits the secret into n shares, any threshold of them recover it. The secret is bip39
+// entropy, 16 - 32 bytes in steps of 4, the shares of these lengths encode to words without ambiguity.
+func Split(secret []byte, n, threshold int) ([][]byte, error) {
+	if len(secret) < 16 || len(secret) > 32 || len(secret)%4 != 0 {
+		return nil, xerrors.Errorf("invalid secret length %d, need 16 - 32 bytes in steps of 4", len(secret))
+	}
+	if threshold < 2 || threshold > n || n > 255 {
+		return nil, xerrors.Errorf("invalid threshold %d of %d shares, need 2 <= threshold <= shares <= 255", threshold, n)
+	}
+
+	shares := make([][]byte, n)
+	for i := range shares {
+		shares[i] = make([]byte, len(secret)+shareOverhead)
+		shares[i][0] = byte(i + 1)
+		shares[i][1] = byte(threshold)
+	}
+
+	// every byte of the secret is the constant term of its own random polynomial of degree threshold-1
+	coeffs := make([]byte, threshold)
+	for j, s := range secret {
+		if _, err := rand.Read(coeffs[1:]); err != nil {
+			return nil, err
+		}
+		coeffs[0] = s
+
+		for i := range shares {
+			x := shares[i][0]
+			var y byte
+			for k := threshold - 1; k >= 0; k-- {
+				y = mul(y, x) ^ coeffs[k]
+			}
+			shares[i][2+j] = y
+		}
+	}
+
+	for i := range shares {
+		shares[i][len(shares[i])-1] = checksum(shares[i][:len(shares[i])-1])
+	}
+
+	return shares, nil
+}
+
+// Combine recovers the secret from at least threshold shares
+func Combine(shares [][]byte) ([]byte, error) {
+	if len(shares) == 0 {
+		return nil, xerrors.New("no shares")
+	}
+
+	for _, s := range shares {
+		if err := Check(s); err != nil {
+			return nil, err
+		}
+	}
+
+	size := len(shares[0])
+	threshold := int(shares[0][1])
+	seen := make(map[byte]bool)
+	for _, s := range shares {
+		if len(s) != size {
+			return nil, xerrors.New("shares have different lengths")
+		}
+		if int(s[1]) != threshold {
+			return nil, xerrors.New("shares have different thresholds")
+		}
+		if seen[s[0]] {
+			return nil, xerrors.Errorf("duplicate share %d", s[0])
+		}
+		seen[s[0]] = true
+	}
+	if len(shares) < threshold {
+		return nil, xerrors.Errorf("need %d shares, got %d", threshold, len(shares))
+	}
+	shares = shares[:threshold]
+
+	// lagrange interpolation at x = 0
+	secret := make([]byte, size-shareOverhead)
+	for i, si := range shares {
+		basis := byte(1)
+		for j, sj := range shares {
+			if i == j {
+				continue
+			}
+			basis = mul(basis, div(sj[0], sj[0]^si[0]))
+		}
+		for b := range secret {
+			secret[b] ^= mul(si[2+b], basis)
+		}
+	}
+
+	return secret, nil
+}
+
+// Check validates a single share, its length, its x and its checksum
+func Check(share []byte) error {
+	if len(share) <= shareOverhead {
+		return xerrors.Errorf("share of %d bytes is too short", len(share))
+	}
+	if share[0] == 0 {
+		return xerrors.New("invalid share 0")
+	}
+	if checksum(share[:len(share)-1]) != share[len(share)-1] {
+		return xerrors.Errorf("checksum mismatch in share %d", share[0])
+	}
+
+	return nil
+}
+
+func checksum(b []byte) byte {
+	h := sha256.Sum256(b)
+	return h[0]
+}
+
+// ToWords encodes a share as words of the bip39 english word list, 11 bits per word
+func ToWords(share []byte) string {
+	wordList := bip39.GetWordList()
+
+	var words []string
+	var acc, bits uint
+	for _, b := range share {
+		acc = acc<<8 | uint(b)
+		bits += 8
+		for bits >= 11 {
+			bits -= 11
+			words = append(words, wordList[(acc>>bits)&0x7ff])
+		}
+	}
+	if bits > 0 {
+		words = append(words, wordList[(acc<<(11-bits))&0x7ff])
+	}
+
+	return strings.Join(words, " ")
+}
+
+// FromWords decodes a share encoded by ToWords
+func FromWords(s string) ([]byte, error) {
+	var share []byte
+	var acc, bits uint
+	for _, w := range strings.Fields(s) {
+		idx, ok := bip39.GetWordIndex(strings.ToLower(w))
+		if !ok {
+			return nil, xerrors.Errorf("unknown word %q", w)
+		}
+
+		acc = acc<<11 | uint(idx)
+		bits += 11
+		for bits >= 8 {
+			bits -= 8
+			share = append(share, byte(acc>>bits))
+		}
+	}
+
+	if acc&(1<<bits-1) != 0 {
+		return nil, xerrors.New("invalid padding, a word is probably mistyped")
+	}
+
+	return share, nil
+}
@@ -0,0 +1,80 @@
+package shamir
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+func TestSplitCombine(t *testing.T) {
+	for _, size := range []int{16, 20, 24, 28, 32} {
+		secret := make([]byte, size)
+		if _, err := rand.Read(secret); err != nil {
+			t.Fatal(err)
+		}
+
+		shares, err := Split(secret, 5, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, pick := range [][]int{{0, 1, 2}, {4, 2, 0}, {1, 3, 4, 0}} {
+			var sub [][]byte
+			for _, i := range pick {
+				decoded, err := FromWords(ToWords(shares[i]))
+				if err != nil {
+					t.Fatal(err)
+				}
+				sub = append(sub, decoded)
+			}
+
+			got, err := Combine(sub)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, secret) {
+				t.Fatalf("size %d shares %v: recovered %x, expected %x", size, pick, got, secret)
+			}
+		}
+
+		if _, err := Combine(shares[:2]); err == nil {
+			t.Fatal("expected 2 of 3 shares to be refused")
+		}
+	}
+}
+
+func TestCombineErrors(t *testing.T) {
+	shares, err := Split([]byte("0123456789abcdef"), 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Combine([][]byte{shares[0], shares[0]}); err == nil {
+		t.Fatal("expected duplicate shares to be refused")
+	}
+
+	corrupt := append([]byte{}, shares[1]...)
+	corrupt[3] ^= 1
+	if _, err := Combine([][]byte{shares[0], corrupt}); err == nil {
+		t.Fatal("expected the checksum to catch the corrupted share")
+	}
+
+	if _, err := Combine([][]byte{{0xab}}); err == nil {
+		t.Fatal("expected a 1 byte share to be refused")
+	}
+	if err := Check([]byte{0xab}); err == nil {
+		t.Fatal("expected a 1 byte share to fail the check")
+	}
+
+	if _, err := FromWords("abandon notaword"); err == nil {
+		t.Fatal("expected unknown words to be refused")
+	}
+
+	if _, err := Split([]byte("0123456789abcdef"), 3, 4); err == nil {
+		t.Fatal("expected threshold above shares to be refused")
+	}
+
+	if _, err := Split([]byte("secret"), 3, 2); err == nil {
+		t.Fatal("expected a secret that is not bip39 entropy to be refused")
+	}
+}
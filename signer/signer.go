@@ -0,0 +1,267 @@
+package signer
+
+import (
+	"bytes"
+	"context"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-jsonrpc"
+	"github.com/filecoin-project/go-state-types/crypto"
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/wallet/key"
+	"github.com/gbrlsnchs/jwt/v3"
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/llifezou/fil-sdk/sigs"
+	"golang.org/x/xerrors"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+var log = logging.Logger("signer")
+
+const (
+	// PermRead allows WalletHas and WalletList
+	PermRead = "read"
+	// PermSign allows WalletSign
+	PermSign = "sign"
+	// PermAdmin allows everything, including WalletNew
+	PermAdmin = "admin"
+)
+
+// Claims is the payload of the tokens accepted by the signer
+type Claims struct {
+	Allow []string
+	// Addresses the token may use, empty allows all addresses
+	Addresses []address.Address `json:",omitempty"`
+}
+
+func (c *Claims) allowed(perm string) bool {
+	for _, p := range c.Allow {
+		if p == perm || p == PermAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Claims) allowedAddress(addr address.Address) bool {
+	if len(c.Addresses) == 0 {
+		return true
+	}
+	for _, a := range c.Addresses {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// NewToken signs the claims with the secret
+func NewToken(secret []byte, c Claims) (string, error) {
+	for _, p := range c.Allow {
+		if p != PermRead && p != PermSign && p != PermAdmin {
+			return "", xerrors.Errorf("unknown permission %s, ps: read, sign, admin", p)
+		}
+	}
+
+	tk, err := jwt.Sign(&c, jwt.NewHS256(secret))
+	if err != nil {
+		return "", err
+	}
+	return string(tk), nil
+}
+
+func verifyToken(secret []byte, token string) (*Claims, error) {
+	var c Claims
+	if _, err := jwt.Verify([]byte(token), jwt.NewHS256(secret), &c); err != nil {
+		return nil, xerrors.Errorf("verifying token: %w", err)
+	}
+	return &c, nil
+}
+
+// CheckFunc is called before every signature, an error refuses to sign
+type CheckFunc func(ctx context.Context, signer address.Address, toSign []byte, meta api.MsgMeta) error
+
+// NewKeyFunc returns a new key of the type for WalletNew
+type NewKeyFunc func(typ types.KeyType) (*key.Key, error)
+
+// Signer serves the keys it holds over the lotus wallet json-rpc api
+type Signer struct {
+	secret []byte
+	check  CheckFunc
+	newKey NewKeyFunc
+
+	lk   sync.Mutex
+	keys map[address.Address]*key.Key
+	list []address.Address
+}
+
+// New returns a signer authenticating tokens with the secret, check and newKey may be nil
+func New(secret []byte, check CheckFunc, newKey NewKeyFunc) (*Signer, error) {
+	if len(secret) < 32 {
+		return nil, xerrors.New("the token secret must have at least 32 bytes")
+	}
+
+	return &Signer{
+		secret: secret,
+		check:  check,
+		newKey: newKey,
+		keys:   make(map[address.Address]*key.Key),
+	}, nil
+}
+
+func (s *Signer) AddKey(k *key.Key) {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	if _, ok := s.keys[k.Address]; ok {
+		return
+	}
+	s.keys[k.Address] = k
+	s.list = append(s.list, k.Address)
+}
+
+// Handler serves the wallet api, every request needs a token in the Authorization header
+func (s *Signer) Handler() http.Handler {
+	rpcServer := jsonrpc.NewServer()
+	rpcServer.Register("Filecoin", &walletAPI{s: s})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if token == "" {
+			log.Warnw("missing token", "remote", r.RemoteAddr)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		c, err := verifyToken(s.secret, token)
+		if err != nil {
+			log.Warnw("invalid token", "remote", r.RemoteAddr, "err", err)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		rpcServer.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), claimsKey{}, c)))
+	})
+}
+
+type claimsKey struct{}
+
+func authorize(ctx context.Context, perm string) (*Claims, error) {
+	c, ok := ctx.Value(claimsKey{}).(*Claims)
+	if !ok || !c.allowed(perm) {
+		return nil, xerrors.Errorf("missing permission %s", perm)
+	}
+	return c, nil
+}
+
+// walletAPI holds only the methods registered on the json-rpc server, see api.Wallet
+type walletAPI struct {
+	s *Signer
+}
+
+func (a *walletAPI) WalletNew(ctx context.Context, typ types.KeyType) (address.Address, error) {
+	if _, err := authorize(ctx, PermAdmin); err != nil {
+		return address.Undef, err
+	}
+	if a.s.newKey == nil {
+		return address.Undef, xerrors.New("creating keys is not supported")
+	}
+
+	k, err := a.s.newKey(typ)
+	if err != nil {
+		return address.Undef, err
+	}
+	a.s.AddKey(k)
+
+	log.Infow("new key", "address", k.Address, "type", typ)
+	return k.Address, nil
+}
+
+func (a *walletAPI) WalletHas(ctx context.Context, addr address.Address) (bool, error) {
+	c, err := authorize(ctx, PermRead)
+	if err != nil {
+		return false, err
+	}
+
+	a.s.lk.Lock()
+	defer a.s.lk.Unlock()
+
+	_, ok := a.s.keys[addr]
+	return ok && c.allowedAddress(addr), nil
+}
+
+func (a *walletAPI) WalletList(ctx context.Context) ([]address.Address, error) {
+	c, err := authorize(ctx, PermRead)
+	if err != nil {
+		return nil, err
+	}
+
+	a.s.lk.Lock()
+	defer a.s.lk.Unlock()
+
+	out := make([]address.Address, 0, len(a.s.list))
+	for _, addr := range a.s.list {
+		if c.allowedAddress(addr) {
+			out = append(out, addr)
+		}
+	}
+	return out, nil
+}
+
+func (a *walletAPI) WalletSign(ctx context.Context, signer address.Address, toSign []byte, meta api.MsgMeta) (*crypto.Signature, error) {
+	c, err := authorize(ctx, PermSign)
+	if err != nil {
+		return nil, err
+	}
+	if !c.allowedAddress(signer) {
+		return nil, xerrors.Errorf("token may not sign with %s", signer)
+	}
+
+	a.s.lk.Lock()
+	k, ok := a.s.keys[signer]
+	a.s.lk.Unlock()
+	if !ok {
+		return nil, xerrors.Errorf("key not found for %s", signer)
+	}
+
+	if a.s.check != nil {
+		if err := a.s.check(ctx, signer, toSign, meta); err != nil {
+			log.Warnw("refused to sign", "signer", signer, "type", meta.Type, "err", err)
+			return nil, xerrors.Errorf("refused to sign: %w", err)
+		}
+	}
+
+	log.Infow("sign", "signer", signer, "type", meta.Type)
+	return sigs.Sign(key.ActSigType(k.Type), k.PrivateKey, toSign)
+}
+
+func (a *walletAPI) WalletExport(context.Context, address.Address) (*types.KeyInfo, error) {
+	return nil, xerrors.New("exporting keys is not supported")
+}
+
+func (a *walletAPI) WalletImport(context.Context, *types.KeyInfo) (address.Address, error) {
+	return address.Undef, xerrors.New("importing keys is not supported")
+}
+
+func (a *walletAPI) WalletDelete(context.Context, address.Address) error {
+	return xerrors.New("deleting keys is not supported")
+}
+
+// ChainMessage decodes the message of a MTChainMsg signing request and checks it is what gets signed
+func ChainMessage(toSign []byte, meta api.MsgMeta) (*types.Message, error) {
+	if meta.Type != api.MTChainMsg {
+		return nil, xerrors.Errorf("expected a chain message, got %s", meta.Type)
+	}
+
+	msg, err := types.DecodeMessage(meta.Extra)
+	if err != nil {
+		return nil, xerrors.Errorf("decoding message: %w", err)
+	}
+	if !bytes.Equal(msg.Cid().Bytes(), toSign) {
+		return nil, xerrors.New("the signed bytes don't match the message")
+	}
+
+	return msg, nil
+}
@@ -0,0 +1,182 @@
+package signer
+
+import (
+	"context"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/crypto"
+	"github.com/filecoin-project/lotus/api"
+	lotusClient "github.com/filecoin-project/lotus/api/client"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/wallet/key"
+	_ "github.com/filecoin-project/lotus/lib/sigs/secp"
+	"github.com/llifezou/fil-sdk/sigs"
+	_ "github.com/llifezou/fil-sdk/sigs/secp"
+	"golang.org/x/xerrors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var secret = []byte("0123456789abcdef0123456789abcdef")
+
+func testKey(t *testing.T, seed string) *key.Key {
+	pk, err := sigs.Generate(crypto.SigTypeSecp256k1, []byte(strings.Repeat(seed, 32)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := key.NewKey(types.KeyInfo{Type: types.KTSecp256k1, PrivateKey: pk})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func testSigner(t *testing.T, check CheckFunc) (*Signer, *key.Key, *key.Key, string) {
+	s, err := New(secret, check, func(typ types.KeyType) (*key.Key, error) {
+		return testKey(t, "c"), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	k1, k2 := testKey(t, "a"), testKey(t, "b")
+	s.AddKey(k1)
+	s.AddKey(k2)
+
+	srv := httptest.NewServer(s.Handler())
+	t.Cleanup(srv.Close)
+
+	return s, k1, k2, srv.URL
+}
+
+func testClient(t *testing.T, url string, c Claims) api.Wallet {
+	token, err := NewToken(secret, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+token)
+	w, closer, err := lotusClient.NewWalletRPCV0(context.Background(), url, header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(closer)
+
+	return w
+}
+
+func TestSignerPermissions(t *testing.T) {
+	_, k1, k2, url := testSigner(t, nil)
+	ctx := context.Background()
+
+	w := testClient(t, url, Claims{Allow: []string{PermRead, PermSign}, Addresses: []address.Address{k1.Address}})
+
+	list, err := w.WalletList(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0] != k1.Address {
+		t.Fatalf("expected only %s to be listed, got %v", k1.Address, list)
+	}
+
+	if has, err := w.WalletHas(ctx, k2.Address); err != nil || has {
+		t.Fatalf("expected %s to be hidden, got %v %v", k2.Address, has, err)
+	}
+
+	data := []byte("hello")
+	sig, err := w.WalletSign(ctx, k1.Address, data, api.MsgMeta{Type: api.MTUnknown})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sigs.Verify(sig, k1.Address, data); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := w.WalletSign(ctx, k2.Address, data, api.MsgMeta{Type: api.MTUnknown}); err == nil {
+		t.Fatal("expected the token to be refused for the other address")
+	}
+
+	if _, err := w.WalletNew(ctx, types.KTSecp256k1); err == nil {
+		t.Fatal("expected WalletNew to require admin")
+	}
+
+	admin := testClient(t, url, Claims{Allow: []string{PermAdmin}})
+	addr, err := admin.WalletNew(ctx, types.KTSecp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if has, err := admin.WalletHas(ctx, addr); err != nil || !has {
+		t.Fatalf("expected the new key to be served, got %v %v", has, err)
+	}
+}
+
+func TestSignerAuth(t *testing.T) {
+	_, _, _, url := testSigner(t, nil)
+
+	for _, token := range []string{"", "invalid"} {
+		req, err := http.NewRequest("POST", url, strings.NewReader(`{"jsonrpc":"2.0","method":"Filecoin.WalletList","params":[],"id":1}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("token %q: expected 401, got %d", token, resp.StatusCode)
+		}
+	}
+
+	if _, err := NewToken(secret, Claims{Allow: []string{"write"}}); err == nil {
+		t.Fatal("expected unknown permission to be refused")
+	}
+}
+
+func TestSignerCheck(t *testing.T) {
+	var checked *types.Message
+	_, k1, _, url := testSigner(t, func(ctx context.Context, signer address.Address, toSign []byte, meta api.MsgMeta) error {
+		msg, err := ChainMessage(toSign, meta)
+		if err != nil {
+			return err
+		}
+		if msg.Value.GreaterThan(types.FromFil(1)) {
+			return xerrors.Errorf("value %s too high", types.FIL(msg.Value))
+		}
+		checked = msg
+		return nil
+	})
+	ctx := context.Background()
+	w := testClient(t, url, Claims{Allow: []string{PermSign}})
+
+	sign := func(fil uint64) error {
+		msg := &types.Message{From: k1.Address, To: k1.Address, Value: types.FromFil(fil)}
+		b, err := msg.Serialize()
+		if err != nil {
+			return err
+		}
+		_, err = w.WalletSign(ctx, k1.Address, msg.Cid().Bytes(), api.MsgMeta{Type: api.MTChainMsg, Extra: b})
+		return err
+	}
+
+	if err := sign(1); err != nil {
+		t.Fatal(err)
+	}
+	if checked == nil {
+		t.Fatal("expected the check to see the message")
+	}
+
+	if err := sign(2); err == nil {
+		t.Fatal("expected the check to refuse the message")
+	}
+
+	if _, err := w.WalletSign(ctx, k1.Address, []byte("other"), api.MsgMeta{Type: api.MTChainMsg, Extra: []byte{}}); err == nil {
+		t.Fatal("expected a mismatching message to be refused")
+	}
+}
@@ -0,0 +1,9 @@
+package util
+
+import (
+	"github.com/ethereum/go-ethereum/console/prompt"
+)
+
+func GetConfirm(msg string) (bool, error) {
+	return prompt.Stdin.PromptConfirm(msg)
+}
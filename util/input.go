@@ -0,0 +1,9 @@
+package util
+
+import (
+	"github.com/ethereum/go-ethereum/console/prompt"
+)
+
+func GetInput(msg string) (string, error) {
+	return prompt.Stdin.PromptInput(msg)
+}
@@ -0,0 +1,99 @@
+package verify
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/crypto"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/ethtypes"
+	"github.com/filecoin-project/lotus/lib/sigs"
+	_ "github.com/filecoin-project/lotus/lib/sigs/delegated"
+	_ "github.com/filecoin-project/lotus/lib/sigs/secp"
+	"golang.org/x/xerrors"
+)
+
+// Node resolves id addresses to the key address signing for them, ps: api.FullNode
+type Node interface {
+	StateAccountKey(ctx context.Context, addr address.Address, tsk types.TipSetKey) (address.Address, error)
+}
+
+// Signer returns the key address signing for addr, id addresses are resolved with the node
+// and need one, f1 / f3 / f4 addresses are returned as they are
+func Signer(ctx context.Context, node Node, addr address.Address) (address.Address, error) {
+	if addr.Protocol() != address.ID {
+		return addr, nil
+	}
+	if node == nil {
+		return address.Undef, xerrors.Errorf("%s is an id address, resolving it needs a node", addr)
+	}
+
+	signer, err := node.StateAccountKey(ctx, addr, types.EmptyTSK)
+	if err != nil {
+		return address.Undef, xerrors.Errorf("resolving the key address of %s: %w", addr, err)
+	}
+	return signer, nil
+}
+
+// Signature verifies the signature of the data. Bls signatures need the bls signer of
+// lotus/lib/sigs/bls registered, which needs filecoin-ffi.
+func Signature(sig *crypto.Signature, signer address.Address, data []byte) error {
+	return sigs.Verify(sig, signer, data)
+}
+
+// Message verifies the signature of a signed message the way lotus does, over the message cid,
+// or over the rlp encoded eth transaction for delegated signatures
+func Message(sm *types.SignedMessage, signer address.Address) error {
+	var digest []byte
+	switch sm.Signature.Type {
+	case crypto.SigTypeDelegated:
+		txArgs, err := ethtypes.EthTxArgsFromUnsignedEthMessage(&sm.Message)
+		if err != nil {
+			return xerrors.Errorf("failed to reconstruct eth transaction: %w", err)
+		}
+		roundTripMsg, err := txArgs.ToUnsignedMessage(sm.Message.From)
+		if err != nil {
+			return xerrors.Errorf("failed to reconstruct filecoin msg: %w", err)
+		}
+		if !sm.Message.Equals(roundTripMsg) {
+			return xerrors.New("ethereum tx failed to roundtrip")
+		}
+
+		digest, err = txArgs.ToRlpUnsignedMsg()
+		if err != nil {
+			return xerrors.Errorf("failed to repack eth rlp message: %w", err)
+		}
+	default:
+		digest = sm.Message.Cid().Bytes()
+	}
+
+	if err := Signature(&sm.Signature, signer, digest); err != nil {
+		return xerrors.Errorf("message %s has invalid signature (type %d): %w", sm.Cid(), sm.Signature.Type, err)
+	}
+	return nil
+}
+
+// ParseSignedMessage decodes a signed message as lotus json, hex encoded cbor or raw cbor
+func ParseSignedMessage(b []byte) (*types.SignedMessage, error) {
+	trimmed := bytes.TrimSpace(b)
+
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		var sm types.SignedMessage
+		if err := json.Unmarshal(trimmed, &sm); err != nil {
+			return nil, xerrors.Errorf("decoding signed message json: %w", err)
+		}
+		return &sm, nil
+	}
+
+	if h, err := hex.DecodeString(string(trimmed)); err == nil {
+		b = h
+	}
+
+	sm, err := types.DecodeSignedMessage(b)
+	if err != nil {
+		return nil, xerrors.Errorf("decoding signed message cbor: %w", err)
+	}
+	return sm, nil
+}
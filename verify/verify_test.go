@@ -0,0 +1,143 @@
+package verify
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/builtin"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/ethtypes"
+	"github.com/filecoin-project/lotus/chain/wallet/key"
+	"github.com/filecoin-project/lotus/lib/sigs"
+	"testing"
+)
+
+type mockNode map[address.Address]address.Address
+
+func (n mockNode) StateAccountKey(_ context.Context, addr address.Address, _ types.TipSetKey) (address.Address, error) {
+	return n[addr], nil
+}
+
+func newKey(t *testing.T, typ types.KeyType) *key.Key {
+	k, err := key.GenerateKey(typ)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func TestSignature(t *testing.T) {
+	k := newKey(t, types.KTSecp256k1)
+	other := newKey(t, types.KTSecp256k1)
+	data := []byte("hello")
+
+	sig, err := sigs.Sign(key.ActSigType(k.Type), k.PrivateKey, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Signature(sig, k.Address, data); err != nil {
+		t.Fatal(err)
+	}
+	if err := Signature(sig, other.Address, data); err == nil {
+		t.Fatal("expected the signature of another key to be refused")
+	}
+
+	id, err := address.NewIDAddress(1234)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Signer(context.Background(), nil, id); err == nil {
+		t.Fatal("expected an id address to need a node")
+	}
+	signer, err := Signer(context.Background(), mockNode{id: k.Address}, id)
+	if err != nil || signer != k.Address {
+		t.Fatalf("expected %s, got %s %v", k.Address, signer, err)
+	}
+}
+
+func TestMessage(t *testing.T) {
+	k := newKey(t, types.KTSecp256k1)
+	msg := types.Message{
+		From:       k.Address,
+		To:         k.Address,
+		Value:      types.FromFil(1),
+		GasLimit:   1000000,
+		GasFeeCap:  abi.NewTokenAmount(100),
+		GasPremium: abi.NewTokenAmount(10),
+	}
+
+	sig, err := sigs.Sign(key.ActSigType(k.Type), k.PrivateKey, msg.Cid().Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sm := &types.SignedMessage{Message: msg, Signature: *sig}
+
+	j, err := json.Marshal(sm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := sm.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, b := range [][]byte{j, c, []byte(hex.EncodeToString(c) + "\n")} {
+		parsed, err := ParseSignedMessage(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := Message(parsed, k.Address); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sm.Message.Value = types.FromFil(2)
+	if err := Message(sm, k.Address); err == nil {
+		t.Fatal("expected a tampered message to be refused")
+	}
+}
+
+func TestDelegatedMessage(t *testing.T) {
+	k := newKey(t, types.KTDelegated)
+	to, err := ethtypes.ParseEthAddress("0x1111111111111111111111111111111111111111")
+	if err != nil {
+		t.Fatal(err)
+	}
+	toAddr, err := to.ToFilecoinAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := types.Message{
+		From:       k.Address,
+		To:         toAddr,
+		Value:      types.FromFil(1),
+		Method:     builtin.MethodsEVM.InvokeContract,
+		GasLimit:   1000000,
+		GasFeeCap:  abi.NewTokenAmount(100),
+		GasPremium: abi.NewTokenAmount(10),
+	}
+
+	txArgs, err := ethtypes.EthTxArgsFromUnsignedEthMessage(&msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest, err := txArgs.ToRlpUnsignedMsg()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := sigs.Sign(key.ActSigType(k.Type), k.PrivateKey, digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sm := &types.SignedMessage{Message: msg, Signature: *sig}
+	if err := Message(sm, k.Address); err != nil {
+		t.Fatal(err)
+	}
+	if err := Message(sm, newKey(t, types.KTDelegated).Address); err == nil {
+		t.Fatal("expected the signature to be refused for another f4 address")
+	}
+}
@@ -14,6 +14,7 @@ import (
 	_ "github.com/llifezou/fil-sdk/sigs/bls"
 	_ "github.com/llifezou/fil-sdk/sigs/secp"
 	"github.com/llifezou/fil-wallet/config"
+	"github.com/llifezou/fil-wallet/mnemonic"
 	"github.com/llifezou/fil-wallet/util"
 	"github.com/llifezou/hdwallet"
 	"github.com/urfave/cli/v2"
@@ -75,75 +76,35 @@ func getAccount(cctx *cli.Context) (*key.Key, error) {
 		return nk, nil
 	}
 
-	if conf.Account.Mnemonic == "" {
-		return nil, xerrors.New("mnemonic is null")
-	}
-
-	var password = ""
-	if conf.Account.Password {
-		color.Red("密码参与派生，请保存好密码！")
-		color.Red("the password is involved in the derivation, please save the password!")
-
-		fmt.Printf("\n")
-
-		var err error
-		password, err = util.GetPassword(true)
-		if err != nil {
-			color.Red(fmt.Sprintf("Failed get password: %s", err.Error()))
-			os.Exit(1)
-		}
-	}
-
-	t := cctx.String("type")
-	var sigType crypto.SigType
-	switch t {
-	case "secp256k1":
-		sigType = crypto.SigTypeSecp256k1
-	case "bls":
-		sigType = crypto.SigTypeBLS
-	default:
-		return nil, xerrors.Errorf("--type: %s, TypeUnknown", t)
-	}
-
-	seed, err := hdwallet.GenerateSeedFromMnemonic(conf.Account.Mnemonic, password)
-	if err != nil {
-		return nil, err
-	}
-
-	index := cctx.Int("index")
-	path := hdwallet.FilPath(index)
-	log.Infow("wallet info", "type", t, "index", index, "path", path)
-
-	extendSeed, err := hdwallet.GetExtendSeedFromPath(path, seed)
+	seed, err := accountSeed()
 	if err != nil {
 		return nil, err
 	}
 
-	pk, err := sigs.Generate(sigType, extendSeed)
-	if err != nil {
-		return nil, err
-	}
-
-	ki := types.KeyInfo{
-		Type:       types.KeyType(t),
-		PrivateKey: pk,
-	}
+	return deriveKey(seed, cctx.String("type"), cctx.Int("index"))
+}
 
-	nk, err := key.NewKey(ki)
+func getAccountList(cctx *cli.Context, index int) (*key.Key, error) {
+	seed, err := accountSeed()
 	if err != nil {
 		return nil, err
 	}
 
-	return nk, nil
+	return deriveKey(seed, cctx.String("type"), index)
 }
 
-func getAccountList(cctx *cli.Context, index int) (*key.Key, error) {
+// accountSeed returns the seed of the mnemonic, asking for the password when it's involved in the derivation
+func accountSeed() ([]byte, error) {
 	conf := config.Conf()
 
 	if conf.Account.Mnemonic == "" {
 		return nil, xerrors.New("mnemonic is null")
 	}
 
+	if err := mnemonic.Validate(conf.Account.Mnemonic); err != nil {
+		return nil, err
+	}
+
 	var password = ""
 	if conf.Account.Password {
 		color.Red("密码参与派生，请保存好密码！")
@@ -159,7 +120,33 @@ func getAccountList(cctx *cli.Context, index int) (*key.Key, error) {
 		}
 	}
 
-	t := cctx.String("type")
+	return mnemonicSeed(conf.Account.Mnemonic, password)
+}
+
+// mnemonicSeed derives the seed from the mnemonic exactly as it is written, the seed of a mnemonic
+// with irregular spacing differs from the seed of its normalized form, so it keeps its addresses
+func mnemonicSeed(m, password string) ([]byte, error) {
+	seed, err := hdwallet.GenerateSeedFromMnemonic(m, password)
+	if err != nil {
+		return nil, xerrors.Errorf("the mnemonic must be lowercase words separated by single spaces: %w", err)
+	}
+
+	if mnemonic.Normalize(m) != m {
+		color.Red("助记词包含多余的空白字符，派生的地址与规范形式的助记词不同，请勿修改 config 中的助记词！")
+		color.Red("the mnemonic has irregular whitespace and derives different addresses than its normalized form, don't edit the mnemonic in the config!")
+	}
+
+	return seed, nil
+}
+
+// deriveKey derives the key of the type at the index of the filecoin hd path
+func deriveKey(seed []byte, t string, index int) (*key.Key, error) {
+	log.Infow("wallet info", "type", t, "index", index, "path", hdwallet.FilPath(index))
+	return newHDKey(seed, t, index)
+}
+
+// newHDKey is deriveKey without the log, for deriving many keys
+func newHDKey(seed []byte, t string, index int) (*key.Key, error) {
 	var sigType crypto.SigType
 	switch t {
 	case "secp256k1":
@@ -170,15 +157,7 @@ func getAccountList(cctx *cli.Context, index int) (*key.Key, error) {
 		return nil, xerrors.Errorf("--type: %s, TypeUnknown", t)
 	}
 
-	seed, err := hdwallet.GenerateSeedFromMnemonic(conf.Account.Mnemonic, password)
-	if err != nil {
-		return nil, err
-	}
-
-	path := hdwallet.FilPath(index)
-	log.Infow("wallet info", "type", t, "index", index, "path", path)
-
-	extendSeed, err := hdwallet.GetExtendSeedFromPath(path, seed)
+	extendSeed, err := hdwallet.GetExtendSeedFromPath(hdwallet.FilPath(index), seed)
 	if err != nil {
 		return nil, err
 	}
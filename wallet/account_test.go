@@ -0,0 +1,26 @@
+package wallet
+
+import "testing"
+
+func TestMnemonicSeedKeepsSpacing(t *testing.T) {
+	for m, want := range map[string]string{
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about":  "f1qode47ievxlxzk6z2viuovedabmn3tq6t57uqhq",
+		"abandon  abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about": "f1s43yb4dotmkqsitwa2bdcxuqk4jcjs4caa2g5xi",
+	} {
+		seed, err := mnemonicSeed(m, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		nk, err := newHDKey(seed, "secp256k1", 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if nk.Address.String() != want {
+			t.Fatalf("%q derives %s, expected %s", m, nk.Address, want)
+		}
+	}
+
+	if _, err := mnemonicSeed("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about ", ""); err == nil {
+		t.Fatal("expected a mnemonic the seed can't be derived from to be refused")
+	}
+}
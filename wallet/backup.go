@@ -0,0 +1,208 @@
+package wallet
+
+import (
+	"encoding/hex"
+	"fmt"
+	"github.com/fatih/color"
+	"github.com/llifezou/fil-wallet/config"
+	"github.com/llifezou/fil-wallet/mnemonic"
+	"github.com/llifezou/fil-wallet/shamir"
+	"github.com/llifezou/fil-wallet/util"
+	"github.com/llifezou/hdwallet"
+	"github.com/tyler-smith/go-bip39"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+	"strings"
+)
+
+var backupCmd = &cli.Command{
+	Name:  "backup",
+	Usage: "Back up the mnemonic as shamir secret shares",
+	Subcommands: []*cli.Command{
+		backupSplitCmd,
+		backupCombineCmd,
+	},
+}
+
+var backupSplitCmd = &cli.Command{
+	Name:  "split",
+	Usage: "Split the mnemonic into shares, any threshold of them recover it",
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:  "shares",
+			Usage: "number of shares",
+			Value: 5,
+		},
+		&cli.IntFlag{
+			Name:  "threshold",
+			Usage: "number of shares needed to recover the mnemonic",
+			Value: 3,
+		},
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "share format, ps: words, hex",
+			Value: "words",
+		},
+		&cli.StringFlag{
+			Name:  "conf-path",
+			Usage: "config.yaml path",
+			Value: "",
+		},
+	},
+	Before: func(c *cli.Context) error {
+		config.InitConfig(c.String("conf-path"))
+		return nil
+	},
+	Action: func(cctx *cli.Context) error {
+		conf := config.Conf()
+		if conf.Account.Mnemonic == "" {
+			return xerrors.New("mnemonic is null")
+		}
+
+		format := cctx.String("format")
+		if format != "words" && format != "hex" {
+			return xerrors.Errorf("--format: %s, must be words / hex", format)
+		}
+
+		m := mnemonic.Normalize(conf.Account.Mnemonic)
+		if err := mnemonic.Validate(m); err != nil {
+			return err
+		}
+		// the shares recover the normalized mnemonic, which derives other addresses
+		if m != conf.Account.Mnemonic {
+			return xerrors.New("the mnemonic has irregular whitespace, the recovered mnemonic would derive different addresses, back up the mnemonic as written instead")
+		}
+
+		entropy, err := bip39.EntropyFromMnemonic(m)
+		if err != nil {
+			return err
+		}
+
+		shares, err := shamir.Split(entropy, cctx.Int("shares"), cctx.Int("threshold"))
+		if err != nil {
+			return err
+		}
+
+		color.Red("分开保存每一份，任意 %d 份即可恢复助记词！", cctx.Int("threshold"))
+		color.Red("Store every share in a different place, any %d of them recover the mnemonic!", cctx.Int("threshold"))
+		if conf.Account.Password {
+			color.Red("密码参与派生，但不包含在分片中，请另外保存好密码！")
+			color.Red("the password is involved in the derivation but not in the shares, please save the password separately!")
+		}
+
+		fmt.Printf("\n")
+		for i, s := range shares {
+			if format == "hex" {
+				fmt.Printf("share %d: %s\n", i+1, hex.EncodeToString(s))
+			} else {
+				fmt.Printf("share %d: %s\n", i+1, shamir.ToWords(s))
+			}
+		}
+
+		return nil
+	},
+}
+
+var backupCombineCmd = &cli.Command{
+	Name:  "combine",
+	Usage: "Recover the mnemonic from the shares and verify it by deriving the address of index 0",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "type",
+			Usage: "wallet type of the verification address, ps: secp256k1, bls",
+			Value: "secp256k1",
+		},
+		&cli.BoolFlag{
+			Name:  "password",
+			Usage: "the password is involved in the derivation",
+			Value: false,
+		},
+		&cli.StringFlag{
+			Name:  "expect",
+			Usage: "address of index 0 the recovered mnemonic must derive",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		var shares [][]byte
+		for threshold := 1; len(shares) < threshold; {
+			line, err := util.GetInput(fmt.Sprintf("share %d: ", len(shares)+1))
+			if err != nil {
+				return err
+			}
+			line = strings.TrimSpace(line)
+			if line == "" {
+				continue
+			}
+
+			s, err := parseShare(line)
+			if err != nil {
+				color.Red(fmt.Sprintf("invalid share: %s", err.Error()))
+				continue
+			}
+			shares = append(shares, s)
+			threshold = int(shares[0][1])
+		}
+
+		entropy, err := shamir.Combine(shares)
+		if err != nil {
+			return err
+		}
+
+		m, err := bip39.NewMnemonic(entropy)
+		if err != nil {
+			return err
+		}
+
+		var password string
+		if cctx.Bool("password") {
+			password, err = util.GetPassword(false)
+			if err != nil {
+				return err
+			}
+		}
+
+		seed, err := hdwallet.GenerateSeedFromMnemonic(m, password)
+		if err != nil {
+			return err
+		}
+
+		nk, err := deriveKey(seed, cctx.String("type"), 0)
+		if err != nil {
+			return err
+		}
+
+		if expect := cctx.String("expect"); expect != "" && expect != nk.Address.String() {
+			return xerrors.Errorf("the recovered mnemonic derives %s, not %s, check the shares and the password", nk.Address, expect)
+		}
+
+		color.Red("一定保存好助记词，丢失助记词将导致所有财产损失！")
+		color.Red("Be sure to save mnemonic. Losing mnemonic will cause all property damage!")
+
+		fmt.Printf("\n")
+		fmt.Println(m)
+		fmt.Printf("index 0 address: %s\n", nk.Address)
+		return nil
+	},
+}
+
+// parseShare decodes a share printed by split, as hex or as words, with or without the "share N:" prefix
+func parseShare(s string) ([]byte, error) {
+	if i := strings.Index(s, ":"); i >= 0 {
+		s = strings.TrimSpace(s[i+1:])
+	}
+
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		b, err = shamir.FromWords(s)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// the threshold is read from the first share, a short one must not get that far
+	if err := shamir.Check(b); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
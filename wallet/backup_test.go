@@ -0,0 +1,28 @@
+package wallet
+
+import (
+	"encoding/hex"
+	"github.com/llifezou/fil-wallet/shamir"
+	"testing"
+)
+
+func TestParseShare(t *testing.T) {
+	if _, err := parseShare("ab"); err == nil {
+		t.Fatal("expected a 1 byte share to be refused")
+	}
+
+	shares, err := shamir.Split([]byte("0123456789abcdef"), 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range []string{hex.EncodeToString(shares[0]), "share 1: " + shamir.ToWords(shares[0])} {
+		b, err := parseShare(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(b) != hex.EncodeToString(shares[0]) {
+			t.Fatalf("share %q decoded to %x", s, b)
+		}
+	}
+}
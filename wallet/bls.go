@@ -0,0 +1,218 @@
+package wallet
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	ffi "github.com/filecoin-project/filecoin-ffi"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/crypto"
+	"github.com/filecoin-project/lotus/chain/consensus"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/ipfs/go-cid"
+	"github.com/llifezou/fil-wallet/client"
+	"github.com/llifezou/fil-wallet/config"
+	"github.com/llifezou/fil-wallet/envelope"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+)
+
+var blsCmd = &cli.Command{
+	Name:  "bls",
+	Usage: "Aggregate bls signatures and verify aggregates",
+	Subcommands: []*cli.Command{
+		blsAggregateCmd,
+		blsVerifyAggregateCmd,
+	},
+}
+
+var blsAggregateCmd = &cli.Command{
+	Name:      "aggregate",
+	Usage:     "Aggregate bls signatures, printed like `lotus wallet sign` and the hex of sign",
+	ArgsUsage: "<hexSignature> [hexSignature...]",
+	Action: func(cctx *cli.Context) error {
+		if !cctx.Args().Present() {
+			return fmt.Errorf("must specify the signatures to aggregate")
+		}
+
+		var sigs []crypto.Signature
+		for _, arg := range cctx.Args().Slice() {
+			sig, err := parseBlsSignature(arg)
+			if err != nil {
+				return err
+			}
+			sigs = append(sigs, *sig)
+		}
+
+		agg, err := consensus.AggregateSignatures(sigs)
+		if err != nil {
+			return err
+		}
+
+		b, err := agg.MarshalBinary()
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(hex.EncodeToString(b))
+		return nil
+	},
+}
+
+var blsVerifyAggregateCmd = &cli.Command{
+	Name:      "verify-aggregate",
+	Usage:     "Verify an aggregate signature of (address, message) pairs, or the bls message aggregate of a block",
+	ArgsUsage: "--signature <hexSignature> <address> <message> [<address> <message>...] | --block <cid>",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "signature",
+			Usage: "aggregate signature, hex like `lotus wallet sign`",
+		},
+		&cli.StringFlag{
+			Name:  "block",
+			Usage: "verify the bls message aggregate of the block, needs the node",
+		},
+		signModeFlag,
+		signInputFlag,
+		&cli.StringFlag{
+			Name:  "conf-path",
+			Usage: "config.yaml path, only read when the node is needed",
+			Value: "",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.IsSet("block") {
+			return verifyBlockAggregate(cctx)
+		}
+
+		if !cctx.IsSet("signature") {
+			return fmt.Errorf("must specify --signature or --block")
+		}
+		if cctx.NArg() == 0 || cctx.NArg()%2 != 0 {
+			return fmt.Errorf("must specify (address, message) pairs")
+		}
+
+		sig, err := parseBlsSignature(cctx.String("signature"))
+		if err != nil {
+			return err
+		}
+
+		var msgs []ffi.Message
+		var pubks []ffi.PublicKey
+		seen := make(map[string]bool)
+		args := cctx.Args().Slice()
+		for i := 0; i < len(args); i += 2 {
+			addr, err := address.NewFromString(args[i])
+			if err != nil {
+				return err
+			}
+			signer, err := verifySigner(cctx, addr)
+			if err != nil {
+				return err
+			}
+			if signer.Protocol() != address.BLS {
+				return xerrors.Errorf("%s is not a bls address", addr)
+			}
+
+			data, err := readSignInput(cctx.String("input"), args[i+1])
+			if err != nil {
+				return err
+			}
+			payload, err := envelope.Payload(cctx.String("mode"), data)
+			if err != nil {
+				return err
+			}
+
+			// the bls scheme of filecoin refuses aggregates over a message signed twice
+			if seen[string(payload)] {
+				return xerrors.Errorf("message %d is repeated, an aggregate needs distinct messages, e.g. the statement followed by the signing address", i/2+1)
+			}
+			seen[string(payload)] = true
+
+			var pubk ffi.PublicKey
+			copy(pubk[:], signer.Payload())
+			msgs = append(msgs, payload)
+			pubks = append(pubks, pubk)
+		}
+
+		var sigS ffi.Signature
+		copy(sigS[:], sig.Data)
+		if !ffi.HashVerify(&sigS, msgs, pubks) {
+			fmt.Println("invalid signature")
+			return xerrors.New("bls aggregate signature failed to verify")
+		}
+		fmt.Println("valid signature")
+		return nil
+	},
+}
+
+func verifyBlockAggregate(cctx *cli.Context) error {
+	blkCid, err := cid.Parse(cctx.String("block"))
+	if err != nil {
+		return err
+	}
+
+	config.InitConfig(cctx.String("conf-path"))
+	conf := config.Conf()
+	api, closer, err := client.NewLotusAPI(conf.Chain.RpcAddr, conf.Chain.Token)
+	if err != nil {
+		return err
+	}
+	defer closer()
+	ctx := context.Background()
+
+	blk, err := api.ChainGetBlock(ctx, blkCid)
+	if err != nil {
+		return err
+	}
+	if blk.BLSAggregate == nil {
+		return xerrors.Errorf("block %s has no bls aggregate", blkCid)
+	}
+
+	ms, err := api.ChainGetBlockMessages(ctx, blkCid)
+	if err != nil {
+		return err
+	}
+
+	// the senders are resolved in the parent state, like the node validating the block
+	parents := types.NewTipSetKey(blk.Parents...)
+	var msgs []cid.Cid
+	var pubks [][]byte
+	for _, m := range ms.BlsMessages {
+		signer, err := api.StateAccountKey(ctx, m.From, parents)
+		if err != nil {
+			return xerrors.Errorf("resolving the key address of %s: %w", m.From, err)
+		}
+		if signer.Protocol() != address.BLS {
+			return xerrors.Errorf("sender %s of bls message %s is not a bls address", m.From, m.Cid())
+		}
+
+		msgs = append(msgs, m.Cid())
+		pubks = append(pubks, signer.Payload())
+	}
+
+	if err := consensus.VerifyBlsAggregate(ctx, blk.BLSAggregate, msgs, pubks); err != nil {
+		fmt.Println("invalid signature")
+		return err
+	}
+	fmt.Printf("valid bls aggregate of %d messages\n", len(msgs))
+	return nil
+}
+
+// parseBlsSignature decodes a bls signature, hex of the type byte and the signature data
+func parseBlsSignature(s string) (*crypto.Signature, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	var sig crypto.Signature
+	if err := sig.UnmarshalBinary(b); err != nil {
+		return nil, err
+	}
+	if sig.Type != crypto.SigTypeBLS || len(sig.Data) != ffi.SignatureBytes {
+		return nil, xerrors.Errorf("%s is not a bls signature", s)
+	}
+
+	return &sig, nil
+}
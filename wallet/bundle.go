@@ -0,0 +1,314 @@
+package wallet
+
+import (
+	"encoding/hex"
+	"fmt"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/llifezou/fil-wallet/bundle"
+	"github.com/llifezou/fil-wallet/client"
+	"github.com/llifezou/fil-wallet/config"
+	"github.com/llifezou/fil-wallet/util"
+	"github.com/llifezou/fil-wallet/verify"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+	"os"
+	"text/tabwriter"
+)
+
+var msigSignBundleCmd = &cli.Command{
+	Name:      "sign-bundle",
+	Usage:     "Sign the approve messages of this wallet in a proposal bundle, works offline",
+	ArgsUsage: "<bundle>",
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 1 {
+			return fmt.Errorf("must specify the proposal bundle")
+		}
+		path := cctx.Args().First()
+
+		b, err := bundle.Load(path)
+		if err != nil {
+			return err
+		}
+
+		nk, err := getAccount(cctx)
+		if err != nil {
+			return err
+		}
+
+		var signed int
+		for i := range b.Approvals {
+			a := &b.Approvals[i]
+			if a.Signer != nk.Address || a.Signature != nil {
+				continue
+			}
+
+			if err := b.CheckApproval(a.Message); err != nil {
+				return err
+			}
+			if a.Message.From != nk.Address {
+				return xerrors.Errorf("approve message is sent from %s, not the signer %s", a.Message.From, nk.Address)
+			}
+
+			if err := printBundle(b); err != nil {
+				return err
+			}
+			if err := printApproval(a.Message); err != nil {
+				return err
+			}
+
+			if err := checkPolicy(cctx, a.Message); err != nil {
+				return err
+			}
+
+			if !cctx.Bool("yes") {
+				ok, err := util.GetConfirm("Sign this approval?")
+				if err != nil {
+					return err
+				}
+				if !ok {
+					return xerrors.New("approval not confirmed")
+				}
+			}
+
+			sm, err := signMessage(nk, a.Message)
+			if err != nil {
+				return err
+			}
+			a.Signature = &sm.Signature
+			signed++
+		}
+
+		if signed == 0 {
+			return xerrors.Errorf("the bundle has no unsigned approval of %s", nk.Address)
+		}
+
+		if err := b.Save(path); err != nil {
+			return err
+		}
+
+		fmt.Printf("signed %d approval(s) of %s into %s\n", signed, nk.Address, path)
+		return nil
+	},
+}
+
+var msigPushBundleCmd = &cli.Command{
+	Name:      "push-bundle",
+	Usage:     "Push the signed approve messages of a proposal bundle",
+	ArgsUsage: "<bundle>",
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 1 {
+			return fmt.Errorf("must specify the proposal bundle")
+		}
+
+		b, err := bundle.Load(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		conf := config.Conf()
+		var pushed int
+		for _, a := range b.Approvals {
+			if a.Signature == nil {
+				fmt.Printf("approval of %s is not signed yet, skipped\n", a.Signer)
+				continue
+			}
+
+			if err := b.CheckApproval(a.Message); err != nil {
+				return err
+			}
+
+			sm := &types.SignedMessage{Message: *a.Message, Signature: *a.Signature}
+			if err := verify.Message(sm, a.Signer); err != nil {
+				return xerrors.Errorf("approval of %s: %w", a.Signer, err)
+			}
+
+			msgCid, err := client.LotusMpoolPush(conf.Chain.RpcAddr, conf.Chain.Token, sm)
+			if err != nil {
+				return xerrors.Errorf("pushing the approval of %s: %w", a.Signer, err)
+			}
+
+			notePushed(a.Message.From, a.Message.Nonce)
+			recordOutflow(a.Message, msgCid)
+			journalSent(cctx, a.Message, msgCid)
+
+			fmt.Printf("approval of %s sent in message: %s\n", a.Signer, msgCid)
+			fmt.Println(fmt.Sprintf("%s%s", conf.Chain.Explorer, msgCid.String()))
+
+			if err := waitMsg(msgCid.String()); err != nil {
+				return err
+			}
+			pushed++
+		}
+
+		if pushed == 0 {
+			return xerrors.New("the bundle has no signed approval")
+		}
+
+		return nil
+	},
+}
+
+// exportBundle waits for the propose message and writes the pending transaction to a proposal bundle
+func exportBundle(path, msgCidStr string, from, msig, dest address.Address, value types.BigInt, method abi.MethodNum, params []byte) error {
+	ret, err := waitProposal(msgCidStr)
+	if err != nil {
+		return err
+	}
+	if ret.Applied {
+		fmt.Printf("txId: %d ", ret.TxnID)
+		return xerrors.Errorf("the transaction was applied with the proposal (exit %d), there is nothing left to approve", ret.Code)
+	}
+
+	// the proposal hash uses the id address of the proposer
+	conf := config.Conf()
+	proposerID, err := client.LotusStateLookupID(conf.Chain.RpcAddr, conf.Chain.Token, from.String())
+	if err != nil {
+		return err
+	}
+	proposer, err := address.NewFromString(proposerID)
+	if err != nil {
+		return err
+	}
+
+	b := &bundle.Bundle{
+		Multisig: msig,
+		TxID:     uint64(ret.TxnID),
+		Proposer: proposer,
+		To:       dest,
+		Value:    value,
+		Method:   method,
+		Params:   params,
+	}
+
+	h, err := b.Hash()
+	if err != nil {
+		return err
+	}
+	b.ProposalHash = hex.EncodeToString(h)
+
+	if err := b.Save(path); err != nil {
+		return err
+	}
+
+	fmt.Printf("txId: %d, proposal bundle written to %s\n", ret.TxnID, path)
+	return nil
+}
+
+// approveBundle builds the unsigned approve messages of the --signer addresses into the bundle, the
+// nonces are taken now so the signers should not send other messages before the bundle is pushed
+func approveBundle(cctx *cli.Context) error {
+	path := cctx.String("bundle")
+	b, err := bundle.Load(path)
+	if err != nil {
+		return err
+	}
+
+	signers := cctx.StringSlice("signer")
+	if len(signers) == 0 {
+		return fmt.Errorf("must specify the --signer addresses to approve with")
+	}
+
+	maxFee, err := messageMaxFee(cctx)
+	if err != nil {
+		return err
+	}
+
+	conf := config.Conf()
+	msiger := NewMsiger()
+	for _, s := range signers {
+		signer, err := address.NewFromString(s)
+		if err != nil {
+			return err
+		}
+
+		// the offline signer matches the approvals by its key address
+		if signer.Protocol() == address.ID {
+			key, err := client.LotusStateAccountKey(conf.Chain.RpcAddr, conf.Chain.Token, signer.String())
+			if err != nil {
+				return err
+			}
+			signer, err = address.NewFromString(key)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, a := range b.Approvals {
+			if a.Signer == signer {
+				return xerrors.Errorf("the bundle already has an approval of %s", signer)
+			}
+		}
+
+		msg, err := msiger.MsigApproveTxnHash(b.Multisig, b.TxID, b.Proposer, b.To, types.BigInt(b.Value), signer, uint64(b.Method), b.Params)
+		if err != nil {
+			return err
+		}
+
+		msg, err = estimateMessageGas(msg, maxFee)
+		if err != nil {
+			return err
+		}
+
+		msg.Nonce, err = nextNonce(signer)
+		if err != nil {
+			return err
+		}
+
+		if err := b.CheckApproval(msg); err != nil {
+			return err
+		}
+
+		b.Approvals = append(b.Approvals, bundle.Approval{
+			Signer:  signer,
+			Message: msg,
+		})
+		fmt.Printf("approve message of %s added, nonce %d\n", signer, msg.Nonce)
+	}
+
+	out := path
+	if cctx.IsSet("out") {
+		out = cctx.String("out")
+	}
+	if err := b.Save(out); err != nil {
+		return err
+	}
+
+	fmt.Printf("proposal bundle written to %s, sign it with `msig sign-bundle` on the machines of the signers\n", out)
+	return nil
+}
+
+// printBundle prints the transaction of the bundle without the node, for air-gapped machines
+func printBundle(b *bundle.Bundle) error {
+	fmt.Printf("Pending transaction %d of %s:\n", b.TxID, b.Multisig)
+	w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "Proposer:\t%s\n", b.Proposer)
+	fmt.Fprintf(w, "To:\t%s\n", b.To)
+	fmt.Fprintf(w, "Value:\t%s\n", types.FIL(b.Value))
+	fmt.Fprintf(w, "Method:\t%d\n", b.Method)
+	fmt.Fprintf(w, "Params:\t%x\n", b.Params)
+	fmt.Fprintf(w, "Proposal hash:\t%s\n", b.ProposalHash)
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Println()
+	return nil
+}
+
+func printApproval(msg *types.Message) error {
+	fmt.Println("Approve message:")
+	w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "From:\t%s\n", msg.From)
+	fmt.Fprintf(w, "Nonce:\t%d\n", msg.Nonce)
+	fmt.Fprintf(w, "Gas limit:\t%d\n", msg.GasLimit)
+	fmt.Fprintf(w, "Gas fee cap:\t%s\n", types.FIL(msg.GasFeeCap))
+	fmt.Fprintf(w, "Gas premium:\t%s\n", types.FIL(msg.GasPremium))
+	fmt.Fprintf(w, "Max fee:\t%s\n", types.FIL(big.Mul(msg.GasFeeCap, big.NewInt(msg.GasLimit))))
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Println()
+	return nil
+}
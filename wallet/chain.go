@@ -24,6 +24,7 @@ var ChainCmd = &cli.Command{
 	Subcommands: []*cli.Command{
 		decodeCmd,
 		encodeCmd,
+		chainGasCmd,
 	},
 }
 
@@ -0,0 +1,181 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/actors/builtin"
+	"github.com/filecoin-project/lotus/chain/consensus"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/ipfs/go-cid"
+	"github.com/llifezou/fil-wallet/client"
+	"github.com/llifezou/fil-wallet/config"
+	"github.com/llifezou/fil-wallet/util"
+	"github.com/urfave/cli/v2"
+	cbg "github.com/whyrusleeping/cbor-gen"
+	"golang.org/x/xerrors"
+	"os"
+	"reflect"
+	"text/tabwriter"
+)
+
+var yesFlag = &cli.BoolFlag{
+	Name:  "yes",
+	Usage: "sign and send without asking for confirmation, for scripts",
+	Value: false,
+}
+
+// confirmMessage prints a summary of the message and asks the user to confirm it before signing
+func confirmMessage(cctx *cli.Context, msg *types.Message) error {
+	if err := printMessage(cctx, msg); err != nil {
+		return err
+	}
+
+	if cctx.Bool("yes") {
+		return nil
+	}
+
+	ok, err := util.GetConfirm("Sign and send this message?")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return xerrors.New("message not confirmed")
+	}
+
+	return nil
+}
+
+func printMessage(cctx *cli.Context, msg *types.Message) error {
+	conf := config.Conf()
+
+	from := msg.From.String()
+	if label, ok := conf.Account.Labels[from]; ok {
+		from += fmt.Sprintf(" (%s)", label)
+	}
+	if conf.Account.Key != "" {
+		from += " [imported key]"
+	} else {
+		from += fmt.Sprintf(" [%s index %d]", cctx.String("type"), cctx.Int("index"))
+	}
+
+	to := msg.To.String()
+	methodName, paramsStr := "Send", fmt.Sprintf("%x", msg.Params)
+	code, _, _, _, err := client.LotusStateGetActor(conf.Chain.RpcAddr, conf.Chain.Token, msg.To.String())
+	if err != nil {
+		// only a destination that doesn't exist is a new account, other errors must not look like one
+		notFound, lerr := actorNotFound(msg.To)
+		if lerr != nil {
+			return xerrors.Errorf("looking up the destination %s: %w", msg.To, lerr)
+		}
+		if !notFound {
+			return xerrors.Errorf("looking up the destination %s: %w", msg.To, err)
+		}
+
+		to += " (new account)"
+		if msg.Method != builtin.MethodSend {
+			methodName = "unknown method"
+		}
+	} else {
+		codeCid, err := cid.Parse(code)
+		if err != nil {
+			return err
+		}
+
+		to += fmt.Sprintf(" (%s)", builtin.ActorNameByCode(codeCid))
+		methodName, paramsStr = methodInfo(codeCid, msg.Method, msg.Params)
+	}
+
+	maxFee := big.Mul(msg.GasFeeCap, big.NewInt(msg.GasLimit))
+
+	w := tabwriter.NewWriter(os.Stdout, 8, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "From:\t%s\n", from)
+	fmt.Fprintf(w, "To:\t%s\n", to)
+	fmt.Fprintf(w, "Value:\t%s\n", types.FIL(msg.Value))
+	fmt.Fprintf(w, "Method:\t%s(%d)\n", methodName, msg.Method)
+	fmt.Fprintf(w, "Params:\t%s\n", paramsStr)
+	fmt.Fprintf(w, "Nonce:\t%d\n", msg.Nonce)
+	fmt.Fprintf(w, "Gas Limit:\t%d\n", msg.GasLimit)
+	fmt.Fprintf(w, "Gas Fee Cap:\t%s attoFIL\n", msg.GasFeeCap)
+	fmt.Fprintf(w, "Gas Premium:\t%s attoFIL\n", msg.GasPremium)
+	fmt.Fprintf(w, "Max Fee:\t%s\n", types.FIL(maxFee))
+	return w.Flush()
+}
+
+// actorNotFound tells whether the address has no actor at the head, the node reports it with a typed error
+func actorNotFound(addr address.Address) (bool, error) {
+	conf := config.Conf()
+	node, closer, err := client.NewLotusAPI(conf.Chain.RpcAddr, conf.Chain.Token)
+	if err != nil {
+		return false, err
+	}
+	defer closer()
+
+	_, err = node.StateLookupID(context.Background(), addr, types.EmptyTSK)
+	if err == nil {
+		return false, nil
+	}
+	if api.ErrorIsIn(err, []error{&api.ErrActorNotFound{}}) {
+		return true, nil
+	}
+	return false, err
+}
+
+// messageMethodName resolves the method name of the message with the code of its receiver
+func messageMethodName(msg *types.Message) (string, error) {
+	if msg.Method == builtin.MethodSend {
+		return "Send", nil
+	}
+
+	conf := config.Conf()
+	code, _, _, _, err := client.LotusStateGetActor(conf.Chain.RpcAddr, conf.Chain.Token, msg.To.String())
+	if err != nil {
+		return "", xerrors.Errorf("getting receiver actor %s: %w", msg.To, err)
+	}
+
+	codeCid, err := cid.Parse(code)
+	if err != nil {
+		return "", err
+	}
+
+	name, _ := methodInfo(codeCid, msg.Method, msg.Params)
+	return name, nil
+}
+
+// methodInfo looks up the method name in the actor registry and decodes the params to json,
+// params that can't be decoded are returned as hex
+func methodInfo(code cid.Cid, method abi.MethodNum, params []byte) (string, string) {
+	paramsStr := fmt.Sprintf("%x", params)
+	if method == builtin.MethodSend {
+		return "Send", paramsStr
+	}
+
+	m, found := consensus.NewActorRegistry().Methods[code][method]
+	if !found {
+		return "unknown method", paramsStr
+	}
+
+	if len(params) == 0 || m.Params == nil || m.Params.Kind() != reflect.Ptr {
+		return m.Name, paramsStr
+	}
+
+	ptyp, ok := reflect.New(m.Params.Elem()).Interface().(cbg.CBORUnmarshaler)
+	if !ok {
+		return m.Name, paramsStr
+	}
+	if err := ptyp.UnmarshalCBOR(bytes.NewReader(params)); err != nil {
+		return m.Name, paramsStr
+	}
+
+	b, err := json.Marshal(ptyp)
+	if err != nil {
+		return m.Name, paramsStr
+	}
+
+	return m.Name, string(b)
+}
@@ -0,0 +1,112 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/builtin"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/llifezou/fil-wallet/client"
+	"github.com/llifezou/fil-wallet/config"
+	"github.com/llifezou/fil-wallet/fee"
+	"github.com/urfave/cli/v2"
+	"os"
+	"text/tabwriter"
+)
+
+// typical gas limits of common messages, used for rough cost estimates only
+var commonMessages = []struct {
+	name     string
+	gasLimit int64
+}{
+	{"send", 2_000_000},
+	{"miner withdraw", 40_000_000},
+	{"msig approve", 25_000_000},
+}
+
+var chainGasCmd = &cli.Command{
+	Name:  "gas",
+	Usage: "Print the current base fee, its recent trend and the cost of common messages",
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:  "lookback",
+			Usage: "number of epochs of base fee history",
+			Value: 20,
+		},
+		&cli.StringFlag{
+			Name:  "conf-path",
+			Usage: "config.yaml path",
+			Value: "",
+		},
+	},
+	Before: func(c *cli.Context) error {
+		config.InitConfig(c.String("conf-path"))
+		return nil
+	},
+	Action: func(cctx *cli.Context) error {
+		conf := config.Conf()
+		api, closer, err := client.NewLotusAPI(conf.Chain.RpcAddr, conf.Chain.Token)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := context.Background()
+
+		lookback := cctx.Int("lookback")
+		if lookback <= 0 {
+			return fmt.Errorf("lookback must be positive")
+		}
+
+		// the history is fetched once, for the trend and for the estimates of every strategy
+		strategies := []fee.Strategy{fee.Economy, fee.Normal, fee.Urgent}
+		n := lookback
+		for _, s := range strategies {
+			if s.Lookback > n {
+				n = s.Lookback
+			}
+		}
+		fullHistory, err := fee.BaseFeeHistory(ctx, api, n)
+		if err != nil {
+			return err
+		}
+		history := fullHistory
+		if len(history) > lookback {
+			history = history[:lookback]
+		}
+
+		current := history[0].BaseFee
+		oldest := history[len(history)-1]
+		minFee, sum := current, big.Zero()
+		for _, h := range history {
+			minFee = big.Min(minFee, h.BaseFee)
+			sum = big.Add(sum, h.BaseFee)
+		}
+
+		fmt.Printf("Base fee at %d: %s attoFIL\n", history[0].Height, current)
+		fmt.Printf("Last %d epochs: min %s, avg %s, max %s attoFIL\n", len(history),
+			minFee, big.Div(sum, big.NewInt(int64(len(history)))), fee.MaxBaseFee(history))
+		if !oldest.BaseFee.IsZero() {
+			change := float64(big.Sub(current, oldest.BaseFee).Int64()) / float64(oldest.BaseFee.Int64()) * 100
+			fmt.Printf("Trend since %d: %+.1f%%\n", oldest.Height, change)
+		}
+		fmt.Println()
+
+		w := tabwriter.NewWriter(os.Stdout, 8, 4, 2, ' ', 0)
+		fmt.Fprintf(w, "Message\tGas Limit\tStrategy\tPremium\tFee Cap\tExpected Cost\tMax Cost\n")
+		for _, m := range commonMessages {
+			for _, s := range strategies {
+				feeCap, premium, err := s.EstimateWithHistory(ctx, api, fullHistory, builtin.BurntFundsActorAddr, m.gasLimit)
+				if err != nil {
+					return err
+				}
+
+				gasLimit := big.NewInt(m.gasLimit)
+				expected := big.Mul(big.Add(current, premium), gasLimit)
+				fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t%s\t%s\n", m.name, m.gasLimit, s.Name, premium, feeCap,
+					types.FIL(expected), types.FIL(big.Mul(feeCap, gasLimit)))
+			}
+		}
+
+		return w.Flush()
+	},
+}
@@ -0,0 +1,258 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/builtin"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/ipfs/go-cid"
+	"github.com/llifezou/fil-wallet/client"
+	"github.com/llifezou/fil-wallet/config"
+	"github.com/llifezou/fil-wallet/history"
+	"github.com/llifezou/fil-wallet/journal"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+var memoFlag = &cli.StringFlag{
+	Name:  "memo",
+	Usage: "note the purpose of the message in the journal",
+}
+
+var walletHistory = &cli.Command{
+	Name:  "history",
+	Usage: "List the messages recorded in the local journal or found on chain",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "from",
+			Usage: "only list messages sent from this address",
+		},
+		&cli.StringFlag{
+			Name:  "to",
+			Usage: "only list messages sent to this address",
+		},
+		&cli.StringFlag{
+			Name:  "status",
+			Usage: "only list messages with this status, ps: pending, ok, failed",
+		},
+		&cli.IntFlag{
+			Name:  "limit",
+			Usage: "list at most this many of the latest messages, 0 lists all",
+			Value: 20,
+		},
+		&cli.BoolFlag{
+			Name:  "recheck",
+			Usage: "search the chain for pending messages and record their receipts",
+		},
+		&cli.StringFlag{
+			Name:  "chain",
+			Usage: "list the messages of this address found on chain instead of the journal",
+		},
+		&cli.Int64Flag{
+			Name:  "from-epoch",
+			Usage: "with --chain, the lowest epoch to search, defaults to one day before --to-epoch",
+		},
+		&cli.Int64Flag{
+			Name:  "to-epoch",
+			Usage: "with --chain, the highest epoch to search, defaults to the chain head",
+		},
+		&cli.StringFlag{
+			Name:  "conf-path",
+			Usage: "config.yaml path",
+			Value: "",
+		},
+	},
+	Before: func(c *cli.Context) error {
+		config.InitConfig(c.String("conf-path"))
+		return nil
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.IsSet("chain") {
+			return chainHistory(cctx)
+		}
+
+		j := openJournal()
+		if j == nil {
+			return xerrors.New("journal is disabled, set journal.path in config.yaml")
+		}
+
+		entries, err := j.Entries()
+		if err != nil {
+			return err
+		}
+
+		var from, to address.Address
+		if cctx.IsSet("from") {
+			from, err = address.NewFromString(cctx.String("from"))
+			if err != nil {
+				return err
+			}
+		}
+		if cctx.IsSet("to") {
+			to, err = address.NewFromString(cctx.String("to"))
+			if err != nil {
+				return err
+			}
+		}
+
+		if cctx.Bool("recheck") {
+			conf := config.Conf()
+			for i, e := range entries {
+				if e.Status != journal.StatusPending {
+					continue
+				}
+
+				wait, err := client.LotusStateSearchMsg(conf.Chain.RpcAddr, conf.Chain.Token, e.Cid)
+				if err != nil {
+					log.Warnw("search message", "cid", e.Cid, "err", err)
+					continue
+				}
+				if wait == nil {
+					continue
+				}
+
+				journalConfirmed(e.Cid, wait)
+				entries[i].Status = journal.StatusOk
+				if wait.Receipt.ExitCode != 0 {
+					entries[i].Status = journal.StatusFailed
+				}
+				entries[i].ExitCode = wait.Receipt.ExitCode
+				entries[i].Height = int64(wait.Height)
+			}
+		}
+
+		var list []journal.Entry
+		for _, e := range entries {
+			if e.Message == nil {
+				continue
+			}
+			if from != address.Undef && e.Message.From != from {
+				continue
+			}
+			if to != address.Undef && e.Message.To != to {
+				continue
+			}
+			if s := cctx.String("status"); s != "" && e.Status != s {
+				continue
+			}
+			list = append(list, e)
+		}
+
+		if limit := cctx.Int("limit"); limit > 0 && len(list) > limit {
+			list = list[len(list)-limit:]
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 8, 4, 2, ' ', 0)
+		fmt.Fprintf(w, "Time\tCID\tFrom\tTo\tValue\tMethod\tNonce\tStatus\tHeight\tMemo\n")
+		for _, e := range list {
+			status := e.Status
+			if e.Status == journal.StatusFailed {
+				status = fmt.Sprintf("%s(%d)", e.Status, e.ExitCode)
+			}
+
+			fromStr := e.Message.From.String()
+			if e.Label != "" {
+				fromStr += fmt.Sprintf(" (%s)", e.Label)
+			}
+
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%d\t%s\t%d\t%s\n",
+				e.Time.Format("2006-01-02 15:04:05"), e.Cid, fromStr, e.Message.To, types.FIL(e.Message.Value),
+				e.Message.Method, e.Message.Nonce, status, e.Height, e.Memo)
+		}
+
+		return w.Flush()
+	},
+}
+
+func chainHistory(cctx *cli.Context) error {
+	addr, err := address.NewFromString(cctx.String("chain"))
+	if err != nil {
+		return err
+	}
+
+	conf := config.Conf()
+	api, closer, err := client.NewLotusAPI(conf.Chain.RpcAddr, conf.Chain.Token)
+	if err != nil {
+		return err
+	}
+	defer closer()
+	ctx := context.Background()
+
+	toEpoch := abi.ChainEpoch(cctx.Int64("to-epoch"))
+	if toEpoch <= 0 {
+		head, err := api.ChainHead(ctx)
+		if err != nil {
+			return err
+		}
+		toEpoch = head.Height()
+	}
+
+	fromEpoch := abi.ChainEpoch(cctx.Int64("from-epoch"))
+	if !cctx.IsSet("from-epoch") {
+		fromEpoch = toEpoch - builtin.EpochsInDay
+	}
+	if fromEpoch > toEpoch {
+		return xerrors.Errorf("from-epoch %d is after to-epoch %d", fromEpoch, toEpoch)
+	}
+
+	records, err := history.List(ctx, api, addr, fromEpoch, toEpoch)
+	if err != nil {
+		return err
+	}
+
+	if limit := cctx.Int("limit"); limit > 0 && len(records) > limit {
+		records = records[len(records)-limit:]
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 8, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "Height\tCID\tDirection\tCounterparty\tValue\tMethod\tExitCode\tBurned\n")
+	for _, r := range records {
+		methodName, _ := methodInfo(r.ToCode, r.Message.Method, r.Message.Params)
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s(%d)\t%d\t%s\n",
+			r.Height, r.Cid, r.Direction, r.Counterparty, types.FIL(r.Message.Value),
+			methodName, r.Message.Method, r.ExitCode, types.FIL(r.Burned))
+	}
+
+	return w.Flush()
+}
+
+// openJournal returns nil when the journal is not enabled in the config
+func openJournal() *journal.Journal {
+	conf := config.Conf()
+	if conf.Journal.Path == "" {
+		return nil
+	}
+
+	return journal.New(conf.Journal.Path)
+}
+
+// journalSent records a pushed message, the message is already pushed so errors are only logged
+func journalSent(cctx *cli.Context, msg *types.Message, msgCid cid.Cid) {
+	j := openJournal()
+	if j == nil {
+		return
+	}
+
+	label := config.Conf().Account.Labels[msg.From.String()]
+	err := j.Sent(msgCid.String(), msg, strings.Join(os.Args, " "), label, cctx.String("memo"))
+	if err != nil {
+		log.Errorw("failed to write journal", "cid", msgCid, "err", err)
+	}
+}
+
+func journalConfirmed(msgCidStr string, wait *client.MsgLookup) {
+	j := openJournal()
+	if j == nil {
+		return
+	}
+
+	err := j.Confirmed(msgCidStr, wait.Receipt.ExitCode, int64(wait.Height))
+	if err != nil {
+		log.Errorw("failed to write journal", "cid", msgCidStr, "err", err)
+	}
+}
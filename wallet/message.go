@@ -1,12 +1,17 @@
 package wallet
 
 import (
+	"context"
 	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/chain/wallet/key"
 	"github.com/llifezou/fil-sdk/sigs"
 	"github.com/llifezou/fil-wallet/client"
 	"github.com/llifezou/fil-wallet/config"
+	"github.com/llifezou/fil-wallet/fee"
+	"github.com/urfave/cli/v2"
 	"golang.org/x/xerrors"
 )
 
@@ -42,22 +47,38 @@ func buildMessage(params *SendParams) (*types.Message, error) {
 		msg.GasLimit = 0
 	}
 
-	if params.Nonce != nil {
-		msg.Nonce = *params.Nonce
-	} else {
-		msg.Nonce = 0
+	return &msg, nil
+}
+
+var maxFeeFlag = &cli.StringFlag{
+	Name:  "max-fee",
+	Usage: "max fee of the message, overrides chain.maxFee of the config, e.g. 0.5FIL",
+}
+
+// messageMaxFee returns the --max-fee of the command or the max fee of the config
+func messageMaxFee(cctx *cli.Context) (abi.TokenAmount, error) {
+	if !cctx.IsSet("max-fee") {
+		return config.Conf().Chain.MaxFeeAmount(), nil
 	}
 
-	return &msg, nil
+	maxFee, err := config.ParseMaxFee(cctx.String("max-fee"))
+	if err != nil {
+		return big.Zero(), xerrors.Errorf("parsing --max-fee: %w", err)
+	}
+	return maxFee, nil
 }
 
-func estimateMessageGasAndNonce(msg *types.Message) (*types.Message, error) {
+// estimateMessageGas fills the unset gas fields, a non-zero maxFee limits GasFeeCap * GasLimit
+func estimateMessageGas(msg *types.Message, maxFee abi.TokenAmount) (*types.Message, error) {
+	feeCapSet := msg.GasFeeCap != types.EmptyInt && types.BigCmp(msg.GasFeeCap, types.NewInt(0)) != 0
+	premiumSet := msg.GasPremium != types.EmptyInt && types.BigCmp(msg.GasPremium, types.NewInt(0)) != 0
+
 	if msg.GasLimit == 0 ||
 		msg.GasFeeCap == types.EmptyInt || types.BigCmp(msg.GasFeeCap, types.NewInt(0)) == 0 ||
 		msg.GasPremium == types.EmptyInt || types.BigCmp(msg.GasPremium, types.NewInt(0)) == 0 {
 
 		conf := config.Conf()
-		gasLimit, gasFeeCap, gasPremium, err := client.LotusGasEstimateMessageGas(conf.Chain.RpcAddr, conf.Chain.Token, msg, types.MustParseFIL(conf.Chain.MaxFee).Int64())
+		gasLimit, gasFeeCap, gasPremium, err := client.LotusGasEstimateMessageGas(conf.Chain.RpcAddr, conf.Chain.Token, msg, maxFee)
 		if err != nil {
 			return nil, err
 		}
@@ -83,18 +104,57 @@ func estimateMessageGasAndNonce(msg *types.Message) (*types.Message, error) {
 		}
 	}
 
-	if msg.Nonce == 0 {
-		nonce, err := client.LotusMpoolGetNonce(config.Conf().Chain.RpcAddr, config.Conf().Chain.Token, msg.From.String())
+	if config.Conf().Chain.FeeStrategy != "" && (!feeCapSet || !premiumSet) {
+		feeCap, premium, err := strategyFees(msg)
 		if err != nil {
 			return nil, err
 		}
 
-		msg.Nonce = uint64(nonce)
+		if !feeCapSet {
+			msg.GasFeeCap = feeCap
+		}
+		if !premiumSet {
+			msg.GasPremium = premium
+		}
+		fee.CapFee(msg, maxFee)
+	}
+
+	if total := big.Mul(msg.GasFeeCap, big.NewInt(msg.GasLimit)); !maxFee.IsZero() && total.GreaterThan(maxFee) {
+		return nil, xerrors.Errorf("gas fee cap %s * gas limit %d = %s exceeds the max fee %s", msg.GasFeeCap, msg.GasLimit, types.FIL(total), types.FIL(maxFee))
 	}
 
 	return msg, nil
 }
 
+// strategyFees returns the fee cap and premium of the fee strategy in the config
+func strategyFees(msg *types.Message) (abi.TokenAmount, abi.TokenAmount, error) {
+	conf := config.Conf()
+	s, err := fee.ParseStrategy(conf.Chain.FeeStrategy)
+	if err != nil {
+		return big.Zero(), big.Zero(), err
+	}
+
+	if s == fee.Fixed {
+		feeCap, err := types.ParseFIL(conf.Chain.FeeCap)
+		if err != nil {
+			return big.Zero(), big.Zero(), xerrors.Errorf("parsing feeCap of the fixed fee strategy: %w", err)
+		}
+		premium, err := types.ParseFIL(conf.Chain.GasPremium)
+		if err != nil {
+			return big.Zero(), big.Zero(), xerrors.Errorf("parsing gasPremium of the fixed fee strategy: %w", err)
+		}
+		return abi.TokenAmount(feeCap), abi.TokenAmount(premium), nil
+	}
+
+	api, closer, err := client.NewLotusAPI(conf.Chain.RpcAddr, conf.Chain.Token)
+	if err != nil {
+		return big.Zero(), big.Zero(), err
+	}
+	defer closer()
+
+	return s.Estimate(context.Background(), api, msg.From, msg.GasLimit)
+}
+
 func signMessage(account *key.Key, msg *types.Message) (*types.SignedMessage, error) {
 	mb, err := msg.ToStorageBlock()
 	if err != nil {
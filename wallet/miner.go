@@ -10,21 +10,29 @@ import (
 	"github.com/filecoin-project/go-state-types/big"
 	"github.com/filecoin-project/go-state-types/builtin"
 	"github.com/filecoin-project/go-state-types/builtin/v9/miner"
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/blockstore"
 	"github.com/filecoin-project/lotus/chain/actors"
+	"github.com/filecoin-project/lotus/chain/actors/adt"
+	bt2 "github.com/filecoin-project/lotus/chain/actors/builtin"
+	lminer "github.com/filecoin-project/lotus/chain/actors/builtin/miner"
 	"github.com/filecoin-project/lotus/chain/actors/builtin/power"
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/lib/tablewriter"
 	miner5 "github.com/filecoin-project/specs-actors/v5/actors/builtin/miner"
 	power6 "github.com/filecoin-project/specs-actors/v6/actors/builtin/power"
+	"github.com/ipfs/go-cid"
+	cbor "github.com/ipfs/go-ipld-cbor"
 	"github.com/libp2p/go-libp2p/core/crypto"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/llifezou/fil-wallet/client"
 	"github.com/llifezou/fil-wallet/config"
+	"github.com/llifezou/fil-wallet/util"
+	ma "github.com/multiformats/go-multiaddr"
 	"github.com/urfave/cli/v2"
 	"golang.org/x/xerrors"
 	"os"
 	"strconv"
-	"strings"
 )
 
 var minerCmd = &cli.Command{
@@ -51,6 +59,11 @@ var minerCmd = &cli.Command{
 			Usage: "specify the nonce to use",
 			Value: 0,
 		},
+		overridePolicyFlag,
+		yesFlag,
+		memoFlag,
+		maxFeeFlag,
+		nonceOffsetFlag,
 		&cli.StringFlag{
 			Name:  "type",
 			Usage: "wallet type, ps: secp256k1, bls",
@@ -74,7 +87,12 @@ var minerCmd = &cli.Command{
 	Subcommands: []*cli.Command{
 		newMinerCmd,
 		actorWithdrawCmd,
+		actorRepayDebtCmd,
+		actorAddBalanceCmd,
 		actorSetOwnerCmd,
+		actorCancelOwnerChangeCmd,
+		actorSetPeerIDCmd,
+		actorSetAddrsCmd,
 		actorControl,
 		actorProposeChangeWorker,
 		actorConfirmChangeWorker,
@@ -140,7 +158,7 @@ var newMinerCmd = &cli.Command{
 			return err
 		}
 
-		msgCid, err := send(nk, &types.Message{
+		msgCid, err := send(cctx, nk, &types.Message{
 			To:    power.Address,
 			From:  from,
 			Value: big.Zero(),
@@ -171,6 +189,10 @@ var actorWithdrawCmd = &cli.Command{
 			Usage:    "specify the address of miner actor",
 			Required: true,
 		},
+		&cli.BoolFlag{
+			Name:  "beneficiary",
+			Usage: "send withdraw message from the beneficiary address, limited by the beneficiary quota",
+		},
 	},
 	Action: func(cctx *cli.Context) error {
 		act := cctx.String("actor")
@@ -180,24 +202,40 @@ var actorWithdrawCmd = &cli.Command{
 		}
 
 		conf := config.Conf()
-		ownerStr, _, _, _, _, err := client.LotusStateMinerInfo(conf.Chain.RpcAddr, conf.Chain.Token, act)
+		api, closer, err := client.NewLotusAPI(conf.Chain.RpcAddr, conf.Chain.Token)
 		if err != nil {
 			return err
 		}
+		defer closer()
+		ctx := context.Background()
 
-		owner, err := address.NewFromString(ownerStr)
+		mi, err := api.StateMinerInfo(ctx, maddr, types.EmptyTSK)
 		if err != nil {
-			return err
+			return xerrors.Errorf("getting miner info: %w", err)
 		}
 
-		availableBalance, err := client.LotusStateMinerAvailableBalance(conf.Chain.RpcAddr, conf.Chain.Token, act)
+		available, err := api.StateMinerAvailableBalance(ctx, maddr, types.EmptyTSK)
 		if err != nil {
 			return err
 		}
 
-		available, err := big.FromString(availableBalance)
-		if err != nil {
-			return err
+		sender := mi.Owner
+		if cctx.Bool("beneficiary") {
+			sender = mi.Beneficiary
+		}
+
+		// the withdrawal is paid to the beneficiary and limited by its quota, whoever sends it
+		if mi.Beneficiary != mi.Owner {
+			head, err := api.ChainHead(ctx)
+			if err != nil {
+				return xerrors.Errorf("failed to get the chain head: %w", err)
+			}
+
+			printBeneficiaryTerm(mi)
+			available, err = beneficiaryWithdrawLimit(mi, head.Height(), available)
+			if err != nil {
+				return err
+			}
 		}
 
 		amount := available
@@ -226,18 +264,20 @@ var actorWithdrawCmd = &cli.Command{
 			return err
 		}
 
-		fromStr, err := client.LotusStateAccountKey(conf.Chain.RpcAddr, conf.Chain.Token, owner.String())
+		isMsig, err := isMultisigActor(sender.String())
 		if err != nil {
 			return err
 		}
-		from, err := address.NewFromString(fromStr)
-		if err != nil {
-			return err
+		if isMsig && !cctx.IsSet("via-msig") {
+			return xerrors.Errorf("%s is a multisig, use 'msig withdraw-propose' or --via-msig instead", sender)
 		}
+
+		from, err := roleSender(cctx, sender)
 		if err != nil {
-			return fmt.Errorf("parsing address %s: %w", act, err)
+			return err
 		}
-		msgCid, err := send(nk, &types.Message{
+
+		msgCid, err := send(cctx, nk, &types.Message{
 			To:     maddr,
 			From:   from,
 			Value:  types.NewInt(0),
@@ -255,10 +295,164 @@ var actorWithdrawCmd = &cli.Command{
 	},
 }
 
+var actorRepayDebtCmd = &cli.Command{
+	Name:      "repay-debt",
+	Usage:     "pay down a miner's debt",
+	ArgsUsage: "[amount (FIL)]",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "actor",
+			Usage:    "specify the address of miner actor",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:  "from",
+			Usage: "send the message from the miner's owner or worker, ps: owner, worker",
+			Value: "owner",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		act := cctx.String("actor")
+		maddr, err := address.NewFromString(act)
+		if err != nil {
+			return fmt.Errorf("parsing address %s: %w", act, err)
+		}
+
+		conf := config.Conf()
+		api, closer, err := client.NewLotusAPI(conf.Chain.RpcAddr, conf.Chain.Token)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := context.Background()
+
+		var amount abi.TokenAmount
+		if cctx.Args().Present() {
+			f, err := types.ParseFIL(cctx.Args().First())
+			if err != nil {
+				return xerrors.Errorf("parsing 'amount' argument: %w", err)
+			}
+
+			amount = abi.TokenAmount(f)
+		} else {
+			mact, err := api.StateGetActor(ctx, maddr, types.EmptyTSK)
+			if err != nil {
+				return err
+			}
+
+			store := adt.WrapStore(ctx, cbor.NewCborStore(blockstore.NewAPIBlockstore(api)))
+
+			mst, err := lminer.Load(store, mact)
+			if err != nil {
+				return err
+			}
+
+			amount, err = mst.FeeDebt()
+			if err != nil {
+				return err
+			}
+
+			if amount.IsZero() {
+				return xerrors.Errorf("miner %s has no fee debt", maddr)
+			}
+		}
+
+		from, err := minerSender(cctx, act, cctx.String("from"))
+		if err != nil {
+			return err
+		}
+
+		nk, err := getAccount(cctx)
+		if err != nil {
+			return err
+		}
+
+		msgCid, err := send(cctx, nk, &types.Message{
+			To:     maddr,
+			From:   from,
+			Value:  amount,
+			Method: builtin.MethodsMiner.RepayDebt,
+			Params: nil,
+		})
+		if err != nil {
+			log.Error(err)
+			return err
+		}
+
+		fmt.Printf("Repay debt of %s in message %s\n", types.FIL(amount), msgCid.String())
+		fmt.Println(fmt.Sprintf("%s%s", config.Conf().Chain.Explorer, msgCid.String()))
+		return waitMsg(msgCid.String())
+	},
+}
+
+var actorAddBalanceCmd = &cli.Command{
+	Name:      "add-balance",
+	Usage:     "send funds to the miner actor for pledge collateral",
+	ArgsUsage: "<amount (FIL)>",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "actor",
+			Usage:    "specify the address of miner actor",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:  "from",
+			Usage: "send the message from the miner's owner or worker, ps: owner, worker",
+			Value: "owner",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if !cctx.Args().Present() {
+			return fmt.Errorf("must pass amount to add")
+		}
+
+		act := cctx.String("actor")
+		maddr, err := address.NewFromString(act)
+		if err != nil {
+			return fmt.Errorf("parsing address %s: %w", act, err)
+		}
+
+		f, err := types.ParseFIL(cctx.Args().First())
+		if err != nil {
+			return xerrors.Errorf("parsing 'amount' argument: %w", err)
+		}
+
+		amount := abi.TokenAmount(f)
+		if !amount.GreaterThan(big.Zero()) {
+			return xerrors.Errorf("amount must be greater than zero")
+		}
+
+		from, err := minerSender(cctx, act, cctx.String("from"))
+		if err != nil {
+			return err
+		}
+
+		nk, err := getAccount(cctx)
+		if err != nil {
+			return err
+		}
+
+		msgCid, err := send(cctx, nk, &types.Message{
+			To:     maddr,
+			From:   from,
+			Value:  amount,
+			Method: builtin.MethodSend,
+		})
+		if err != nil {
+			log.Error(err)
+			return err
+		}
+
+		fmt.Printf("Add balance of %s in message %s\n", types.FIL(amount), msgCid.String())
+		fmt.Println(fmt.Sprintf("%s%s", config.Conf().Chain.Explorer, msgCid.String()))
+		return waitMsg(msgCid.String())
+	},
+}
+
 var actorSetOwnerCmd = &cli.Command{
 	Name:      "set-owner",
-	Usage:     "Set owner address (this command should be invoked twice, first with the old owner as the senderAddress, and then with the new owner)",
-	ArgsUsage: "[newOwnerAddress senderAddress]",
+	Usage:     "Set owner address (the old owner proposes the change, then the new owner confirms it; the stage is detected from the pending owner on chain)",
+	ArgsUsage: "[newOwnerAddress (senderAddress)]",
 	Flags: []cli.Flag{
 		&cli.StringFlag{
 			Name:     "actor",
@@ -272,14 +466,17 @@ var actorSetOwnerCmd = &cli.Command{
 		},
 	},
 	Action: func(cctx *cli.Context) error {
-		if !cctx.Bool("really-do-it") {
-			fmt.Println("Pass --really-do-it to actually execute this action")
-			return nil
+		if cctx.NArg() != 1 && cctx.NArg() != 2 {
+			return fmt.Errorf("must pass new owner address")
 		}
 
-		if cctx.NArg() != 2 {
-			return fmt.Errorf("must pass new owner address and sender address")
+		conf := config.Conf()
+		api, closer, err := client.NewLotusAPI(conf.Chain.RpcAddr, conf.Chain.Token)
+		if err != nil {
+			return err
 		}
+		defer closer()
+		ctx := context.Background()
 
 		act := cctx.String("actor")
 		maddr, err := address.NewFromString(act)
@@ -292,42 +489,175 @@ var actorSetOwnerCmd = &cli.Command{
 			return err
 		}
 
-		conf := config.Conf()
-		newAddrIdStr, err := client.LotusStateLookupID(conf.Chain.RpcAddr, conf.Chain.Token, na.String())
+		newAddrId, err := api.StateLookupID(ctx, na, types.EmptyTSK)
 		if err != nil {
-			return err
+			return xerrors.Errorf("looking up new owner address: %w", err)
 		}
 
-		newAddrId, err := address.NewFromString(newAddrIdStr)
+		mi, err := api.StateMinerInfo(ctx, maddr, types.EmptyTSK)
+		if err != nil {
+			return xerrors.Errorf("getting miner info: %w", err)
+		}
+
+		// the old owner proposes the change, the new owner confirms the pending change
+		var fromAddrId address.Address
+		confirming := mi.PendingOwnerAddress != nil
+		if !confirming {
+			if mi.Owner == newAddrId {
+				return fmt.Errorf("owner address already set to %s", na)
+			}
+
+			fmt.Printf("Proposing owner change: %s -> %s\n", mi.Owner, newAddrId)
+			fromAddrId = mi.Owner
+		} else {
+			fmt.Printf("Pending owner change: %s -> %s\n", mi.Owner, *mi.PendingOwnerAddress)
+			if *mi.PendingOwnerAddress != newAddrId {
+				return xerrors.Errorf("owner change to %s is already pending, run 'cancel-owner-change' first", *mi.PendingOwnerAddress)
+			}
+
+			fmt.Printf("Confirming owner change with the new owner %s\n", newAddrId)
+			fromAddrId = newAddrId
+		}
+
+		if cctx.NArg() == 2 {
+			fa, err := address.NewFromString(cctx.Args().Get(1))
+			if err != nil {
+				return err
+			}
+
+			faId, err := api.StateLookupID(ctx, fa, types.EmptyTSK)
+			if err != nil {
+				return err
+			}
+
+			if faId != fromAddrId {
+				return xerrors.Errorf("sender %s does not match %s, which must send this stage of the owner change", fa, fromAddrId)
+			}
+		}
+
+		if !cctx.Bool("really-do-it") {
+			fmt.Println("Pass --really-do-it to actually execute this action")
+			return nil
+		}
+
+		from, err := roleSender(cctx, fromAddrId)
 		if err != nil {
 			return err
 		}
 
-		fa, err := address.NewFromString(cctx.Args().Get(1))
+		nk, err := getAccount(cctx)
 		if err != nil {
 			return err
 		}
 
-		fromAddrIdStr, err := client.LotusStateLookupID(conf.Chain.RpcAddr, conf.Chain.Token, fa.String())
+		// with --via-msig the wallet key proposes the stage through the multisig
+		if !cctx.IsSet("via-msig") && nk.Address != from {
+			return xerrors.Errorf("this stage must be sent from %s, but the wallet address is %s, check --type and --index", from, nk.Address)
+		}
+
+		if confirming {
+			ok, err := util.GetConfirm(fmt.Sprintf("Confirm %s as the new owner of %s?", from, maddr))
+			if err != nil {
+				return err
+			}
+			if !ok {
+				fmt.Println("Aborted")
+				return nil
+			}
+		}
+
+		sp, err := actors.SerializeParams(&newAddrId)
 		if err != nil {
+			return xerrors.Errorf("serializing params: %w", err)
+		}
+
+		msgCid, err := send(cctx, nk, &types.Message{
+			From:   from,
+			To:     maddr,
+			Method: builtin.MethodsMiner.ChangeOwnerAddress,
+			Value:  big.Zero(),
+			Params: sp,
+		})
+		if err != nil {
+			log.Error(err)
 			return err
 		}
 
-		fromAddrId, err := address.NewFromString(fromAddrIdStr)
+		fmt.Printf("Message CID: %s\n", msgCid.String())
+		fmt.Println(fmt.Sprintf("%s%s", config.Conf().Chain.Explorer, msgCid.String()))
+		err = waitMsg(msgCid.String())
 		if err != nil {
 			return err
 		}
 
-		ownerStr, _, _, _, _, err := client.LotusStateMinerInfo(conf.Chain.RpcAddr, conf.Chain.Token, act)
+		updatedMinerInfo, err := api.StateMinerInfo(ctx, maddr, types.EmptyTSK)
+		if err != nil {
+			return xerrors.Errorf("getting miner info: %w", err)
+		}
+
+		if updatedMinerInfo.Owner == newAddrId {
+			fmt.Println("Owner address successfully changed")
+		} else {
+			fmt.Printf("Owner change proposed, run 'set-owner' again with the new owner %s to confirm\n", newAddrId)
+		}
+
+		return nil
+	},
+}
+
+var actorCancelOwnerChangeCmd = &cli.Command{
+	Name:  "cancel-owner-change",
+	Usage: "Cancel a pending owner address change",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "actor",
+			Usage:    "specify the address of miner actor",
+			Required: true,
+		},
+		&cli.BoolFlag{
+			Name:  "really-do-it",
+			Usage: "Actually send transaction performing the action",
+			Value: false,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		conf := config.Conf()
+		api, closer, err := client.NewLotusAPI(conf.Chain.RpcAddr, conf.Chain.Token)
 		if err != nil {
 			return err
 		}
+		defer closer()
+		ctx := context.Background()
 
-		if fromAddrIdStr != ownerStr && fromAddrId != newAddrId {
-			return xerrors.New("from address must either be the old owner or the new owner")
+		act := cctx.String("actor")
+		maddr, err := address.NewFromString(act)
+		if err != nil {
+			return fmt.Errorf("parsing address %s: %w", act, err)
 		}
 
-		sp, err := actors.SerializeParams(&newAddrId)
+		mi, err := api.StateMinerInfo(ctx, maddr, types.EmptyTSK)
+		if err != nil {
+			return xerrors.Errorf("getting miner info: %w", err)
+		}
+
+		if mi.PendingOwnerAddress == nil {
+			return fmt.Errorf("no pending owner change found for miner %s", maddr)
+		}
+
+		fmt.Printf("Cancelling pending owner change: %s -> %s\n", mi.Owner, *mi.PendingOwnerAddress)
+
+		if !cctx.Bool("really-do-it") {
+			fmt.Println("Pass --really-do-it to actually execute this action")
+			return nil
+		}
+
+		from, err := roleSender(cctx, mi.Owner)
+		if err != nil {
+			return err
+		}
+
+		// proposing the current owner clears the pending owner
+		sp, err := actors.SerializeParams(&mi.Owner)
 		if err != nil {
 			return xerrors.Errorf("serializing params: %w", err)
 		}
@@ -337,22 +667,85 @@ var actorSetOwnerCmd = &cli.Command{
 			return err
 		}
 
-		fromStr, err := client.LotusStateAccountKey(conf.Chain.RpcAddr, conf.Chain.Token, fromAddrId.String())
+		msgCid, err := send(cctx, nk, &types.Message{
+			From:   from,
+			To:     maddr,
+			Method: builtin.MethodsMiner.ChangeOwnerAddress,
+			Value:  big.Zero(),
+			Params: sp,
+		})
 		if err != nil {
+			log.Error(err)
 			return err
 		}
-		from, err := address.NewFromString(fromStr)
+
+		fmt.Printf("Message CID: %s\n", msgCid.String())
+		fmt.Println(fmt.Sprintf("%s%s", config.Conf().Chain.Explorer, msgCid.String()))
+		err = waitMsg(msgCid.String())
 		if err != nil {
 			return err
 		}
+
+		updatedMinerInfo, err := api.StateMinerInfo(ctx, maddr, types.EmptyTSK)
+		if err != nil {
+			return xerrors.Errorf("getting miner info: %w", err)
+		}
+
+		if updatedMinerInfo.PendingOwnerAddress != nil {
+			return xerrors.Errorf("pending owner change to %s still on chain", *updatedMinerInfo.PendingOwnerAddress)
+		}
+
+		fmt.Println("Pending owner change cancelled")
+		return nil
+	},
+}
+
+var actorSetPeerIDCmd = &cli.Command{
+	Name:      "set-peer-id",
+	Usage:     "set the peer id of your miner",
+	ArgsUsage: "<peer id>",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "actor",
+			Usage:    "specify the address of miner actor",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:  "from",
+			Usage: "send the message from the miner's owner or worker, ps: owner, worker",
+			Value: "worker",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return fmt.Errorf("must pass peer id")
+		}
+
+		act := cctx.String("actor")
+		maddr, err := address.NewFromString(act)
 		if err != nil {
 			return fmt.Errorf("parsing address %s: %w", act, err)
 		}
 
-		msgCid, err := send(nk, &types.Message{
+		sp, err := serializePeerIDParams(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		from, err := minerSender(cctx, act, cctx.String("from"))
+		if err != nil {
+			return err
+		}
+
+		nk, err := getAccount(cctx)
+		if err != nil {
+			return err
+		}
+
+		msgCid, err := send(cctx, nk, &types.Message{
 			From:   from,
 			To:     maddr,
-			Method: builtin.MethodsMiner.ChangeOwnerAddress,
+			Method: builtin.MethodsMiner.ChangePeerID,
 			Value:  big.Zero(),
 			Params: sp,
 		})
@@ -361,7 +754,77 @@ var actorSetOwnerCmd = &cli.Command{
 			return err
 		}
 
-		fmt.Printf("Message CID: %s\n", msgCid.String())
+		fmt.Printf("Requested peerid change in message %s\n", msgCid.String())
+		fmt.Println(fmt.Sprintf("%s%s", config.Conf().Chain.Explorer, msgCid.String()))
+		return waitMsg(msgCid.String())
+	},
+}
+
+var actorSetAddrsCmd = &cli.Command{
+	Name:      "set-addrs",
+	Usage:     "set addresses that your miner can be publicly dialed on",
+	ArgsUsage: "<multiaddrs>",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "actor",
+			Usage:    "specify the address of miner actor",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:  "from",
+			Usage: "send the message from the miner's owner or worker, ps: owner, worker",
+			Value: "worker",
+		},
+		&cli.BoolFlag{
+			Name:  "unset",
+			Usage: "unset address",
+			Value: false,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		args := cctx.Args().Slice()
+		unset := cctx.Bool("unset")
+		if len(args) == 0 && !unset {
+			return fmt.Errorf("must pass at least one multiaddr, or --unset to clear the addresses")
+		}
+		if len(args) > 0 && unset {
+			return fmt.Errorf("unset can only be used with no arguments")
+		}
+
+		act := cctx.String("actor")
+		maddr, err := address.NewFromString(act)
+		if err != nil {
+			return fmt.Errorf("parsing address %s: %w", act, err)
+		}
+
+		sp, err := serializeMultiaddrsParams(args)
+		if err != nil {
+			return err
+		}
+
+		from, err := minerSender(cctx, act, cctx.String("from"))
+		if err != nil {
+			return err
+		}
+
+		nk, err := getAccount(cctx)
+		if err != nil {
+			return err
+		}
+
+		msgCid, err := send(cctx, nk, &types.Message{
+			From:   from,
+			To:     maddr,
+			Method: builtin.MethodsMiner.ChangeMultiaddrs,
+			Value:  big.Zero(),
+			Params: sp,
+		})
+		if err != nil {
+			log.Error(err)
+			return err
+		}
+
+		fmt.Printf("Requested multiaddrs change in message %s\n", msgCid.String())
 		fmt.Println(fmt.Sprintf("%s%s", config.Conf().Chain.Explorer, msgCid.String()))
 		return waitMsg(msgCid.String())
 	},
@@ -418,13 +881,18 @@ var actorControlList = &cli.Command{
 				return
 			}
 
-			k, err := client.LotusStateAccountKey(conf.Chain.RpcAddr, conf.Chain.Token, a)
+			isMsig, err := isMultisigActor(a)
 			if err != nil {
-				if strings.Contains(err.Error(), "multisig") {
-					fmt.Printf("%s\t%s (multisig) \n", name, a)
-					return
-				}
+				fmt.Printf("%s\t%s: error getting actor: %s\n", name, a, err)
+				return
+			}
+			if isMsig {
+				fmt.Printf("%s\t%s (multisig) \n", name, a)
+				return
+			}
 
+			k, err := client.LotusStateAccountKey(conf.Chain.RpcAddr, conf.Chain.Token, a)
+			if err != nil {
 				fmt.Printf("%s\t%s: error getting account key: %s\n", name, a, err)
 				return
 			}
@@ -564,11 +1032,7 @@ var actorControlSet = &cli.Command{
 			return err
 		}
 
-		fromStr, err := client.LotusStateAccountKey(conf.Chain.RpcAddr, conf.Chain.Token, owner.String())
-		if err != nil {
-			return err
-		}
-		from, err := address.NewFromString(fromStr)
+		from, err := roleSender(cctx, owner)
 		if err != nil {
 			return err
 		}
@@ -576,7 +1040,7 @@ var actorControlSet = &cli.Command{
 			return fmt.Errorf("parsing address %s: %w", act, err)
 		}
 
-		msgCid, err := send(nk, &types.Message{
+		msgCid, err := send(cctx, nk, &types.Message{
 			From:   from,
 			To:     maddr,
 			Method: builtin.MethodsMiner.ChangeWorkerAddress,
@@ -685,11 +1149,7 @@ var actorProposeChangeWorker = &cli.Command{
 			return err
 		}
 
-		fromStr, err := client.LotusStateAccountKey(conf.Chain.RpcAddr, conf.Chain.Token, owner.String())
-		if err != nil {
-			return err
-		}
-		from, err := address.NewFromString(fromStr)
+		from, err := roleSender(cctx, owner)
 		if err != nil {
 			return err
 		}
@@ -697,7 +1157,7 @@ var actorProposeChangeWorker = &cli.Command{
 			return fmt.Errorf("parsing address %s: %w", act, err)
 		}
 
-		msgCid, err := send(nk, &types.Message{
+		msgCid, err := send(cctx, nk, &types.Message{
 			From:   from,
 			To:     maddr,
 			Method: builtin.MethodsMiner.ChangeWorkerAddress,
@@ -803,11 +1263,7 @@ var actorConfirmChangeWorker = &cli.Command{
 			return err
 		}
 
-		fromStr, err := client.LotusStateAccountKey(conf.Chain.RpcAddr, conf.Chain.Token, owner.String())
-		if err != nil {
-			return err
-		}
-		from, err := address.NewFromString(fromStr)
+		from, err := roleSender(cctx, owner)
 		if err != nil {
 			return err
 		}
@@ -815,7 +1271,7 @@ var actorConfirmChangeWorker = &cli.Command{
 			return fmt.Errorf("parsing address %s: %w", act, err)
 		}
 
-		msgCid, err := send(nk, &types.Message{
+		msgCid, err := send(cctx, nk, &types.Message{
 			From:   from,
 			To:     maddr,
 			Method: builtin.MethodsMiner.ConfirmChangeWorkerAddress,
@@ -946,7 +1402,7 @@ var actorProposeChangeBeneficiary = &cli.Command{
 			return xerrors.Errorf("serializing params: %w", err)
 		}
 
-		msgCid, err := send(nk, &types.Message{
+		msgCid, err := send(cctx, nk, &types.Message{
 			From:   mi.Owner,
 			To:     maddr,
 			Method: builtin.MethodsMiner.ChangeBeneficiary,
@@ -1069,7 +1525,7 @@ var actorConfirmChangeBeneficiary = &cli.Command{
 			return xerrors.Errorf("serializing params: %w", err)
 		}
 
-		msgCid, err := send(nk, &types.Message{
+		msgCid, err := send(cctx, nk, &types.Message{
 			From:   fromAddr,
 			To:     maddr,
 			Method: builtin.MethodsMiner.ChangeBeneficiary,
@@ -1101,3 +1557,130 @@ var actorConfirmChangeBeneficiary = &cli.Command{
 		return nil
 	},
 }
+
+// minerSender resolves the sender of the miner's owner or worker, selected by role
+func minerSender(cctx *cli.Context, act string, role string) (address.Address, error) {
+	conf := config.Conf()
+	ownerStr, workerStr, _, _, _, err := client.LotusStateMinerInfo(conf.Chain.RpcAddr, conf.Chain.Token, act)
+	if err != nil {
+		return address.Undef, err
+	}
+
+	var addrStr string
+	switch role {
+	case "owner":
+		addrStr = ownerStr
+	case "worker":
+		addrStr = workerStr
+	default:
+		return address.Undef, xerrors.Errorf("--from: %s, must be owner or worker", role)
+	}
+
+	addr, err := address.NewFromString(addrStr)
+	if err != nil {
+		return address.Undef, err
+	}
+
+	return roleSender(cctx, addr)
+}
+
+func serializePeerIDParams(s string) ([]byte, error) {
+	pid, err := peer.Decode(s)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to parse input as a peerId: %w", err)
+	}
+
+	sp, err := actors.SerializeParams(&miner.ChangePeerIDParams{NewID: abi.PeerID(pid)})
+	if err != nil {
+		return nil, xerrors.Errorf("serializing params: %w", err)
+	}
+
+	return sp, nil
+}
+
+func serializeMultiaddrsParams(args []string) ([]byte, error) {
+	var addrs []abi.Multiaddrs
+	for _, a := range args {
+		maddr, err := ma.NewMultiaddr(a)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %q as a multiaddr: %w", a, err)
+		}
+
+		maddrNop2p, strip := ma.SplitFunc(maddr, func(c ma.Component) bool {
+			return c.Protocol().Code == ma.P_P2P
+		})
+		if strip != nil {
+			fmt.Println("Stripping peerid ", strip, " from ", maddr)
+		}
+
+		addrs = append(addrs, maddrNop2p.Bytes())
+	}
+
+	sp, err := actors.SerializeParams(&miner.ChangeMultiaddrsParams{NewMultiaddrs: addrs})
+	if err != nil {
+		return nil, xerrors.Errorf("serializing params: %w", err)
+	}
+
+	return sp, nil
+}
+
+// beneficiaryWithdrawLimit caps the available balance to what the beneficiary may still withdraw
+// before its term expires, the owner as beneficiary is not limited by a quota (FIP-0029)
+func beneficiaryWithdrawLimit(mi api.MinerInfo, height abi.ChainEpoch, available abi.TokenAmount) (abi.TokenAmount, error) {
+	if mi.Beneficiary == mi.Owner {
+		return available, nil
+	}
+
+	term := mi.BeneficiaryTerm
+	if term == nil {
+		return big.Zero(), xerrors.Errorf("no beneficiary term found for beneficiary %s", mi.Beneficiary)
+	}
+
+	if term.Expiration <= height {
+		return big.Zero(), xerrors.Errorf("beneficiary term expired at %d, current height is %d", term.Expiration, height)
+	}
+
+	remaining := big.Sub(term.Quota, term.UsedQuota)
+	if remaining.LessThanEqual(big.Zero()) {
+		return big.Zero(), xerrors.Errorf("beneficiary quota is used up")
+	}
+
+	return big.Min(available, remaining), nil
+}
+
+// printBeneficiaryTerm prints the beneficiary a withdrawal is paid to and its term
+func printBeneficiaryTerm(mi api.MinerInfo) {
+	fmt.Println("Beneficiary: ", mi.Beneficiary)
+	if term := mi.BeneficiaryTerm; term != nil {
+		fmt.Println("Quota:", types.FIL(term.Quota))
+		fmt.Println("Used Quota:", types.FIL(term.UsedQuota))
+		fmt.Println("Expiration Epoch:", term.Expiration)
+	}
+}
+
+// isMultisigActor checks the actor code of the address, the address must exist on chain
+func isMultisigActor(addr string) (bool, error) {
+	conf := config.Conf()
+	code, _, _, _, err := client.LotusStateGetActor(conf.Chain.RpcAddr, conf.Chain.Token, addr)
+	if err != nil {
+		return false, xerrors.Errorf("failed to look up actor %s: %w", addr, err)
+	}
+
+	codeCid, err := cid.Parse(code)
+	if err != nil {
+		return false, xerrors.Errorf("failed to cid.Parse %s: %w", code, err)
+	}
+
+	return bt2.IsMultisigActor(codeCid), nil
+}
+
+// minerAccountKey resolves an ID address of the miner's owner, worker or control address to its account key
+func minerAccountKey(addr string) (address.Address, error) {
+	conf := config.Conf()
+	keyStr, err := client.LotusStateAccountKey(conf.Chain.RpcAddr, conf.Chain.Token, addr)
+	if err != nil {
+		return address.Undef, err
+	}
+
+	return address.NewFromString(keyStr)
+}
@@ -0,0 +1,47 @@
+package wallet
+
+import (
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/builtin/v9/miner"
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	"testing"
+)
+
+func TestBeneficiaryWithdrawLimit(t *testing.T) {
+	owner, _ := address.NewIDAddress(1000)
+	beneficiary, _ := address.NewIDAddress(1001)
+	available := types.FromFil(100)
+
+	mi := api.MinerInfo{Owner: owner, Beneficiary: owner}
+	if got, err := beneficiaryWithdrawLimit(mi, 10, available); err != nil || !got.Equals(available) {
+		t.Fatalf("expected the owner as beneficiary to be unlimited, got %s %v", got, err)
+	}
+
+	mi.Beneficiary = beneficiary
+	if _, err := beneficiaryWithdrawLimit(mi, 10, available); err == nil {
+		t.Fatal("expected an error without a beneficiary term")
+	}
+
+	mi.BeneficiaryTerm = &miner.BeneficiaryTerm{
+		Quota:      types.FromFil(50),
+		UsedQuota:  types.FromFil(20),
+		Expiration: 100,
+	}
+	if got, err := beneficiaryWithdrawLimit(mi, 10, available); err != nil || !got.Equals(types.FromFil(30)) {
+		t.Fatalf("expected the remaining quota of 30 FIL, got %s %v", got, err)
+	}
+	if got, err := beneficiaryWithdrawLimit(mi, 10, types.FromFil(5)); err != nil || !got.Equals(types.FromFil(5)) {
+		t.Fatalf("expected the available balance under the quota, got %s %v", got, err)
+	}
+
+	if _, err := beneficiaryWithdrawLimit(mi, 100, available); err == nil {
+		t.Fatal("expected an expired term to be refused")
+	}
+
+	mi.BeneficiaryTerm.UsedQuota = big.Add(mi.BeneficiaryTerm.Quota, big.NewInt(1))
+	if _, err := beneficiaryWithdrawLimit(mi, 10, available); err == nil {
+		t.Fatal("expected a used up quota to be refused")
+	}
+}
@@ -19,7 +19,6 @@ import (
 	"github.com/filecoin-project/lotus/chain/actors/adt"
 	bt2 "github.com/filecoin-project/lotus/chain/actors/builtin"
 	"github.com/filecoin-project/lotus/chain/actors/builtin/multisig"
-	"github.com/filecoin-project/lotus/chain/consensus"
 	"github.com/filecoin-project/lotus/chain/types"
 	init2 "github.com/filecoin-project/specs-actors/v2/actors/builtin/init"
 	msig2 "github.com/filecoin-project/specs-actors/v2/actors/builtin/multisig"
@@ -29,11 +28,12 @@ import (
 	"github.com/llifezou/fil-wallet/client"
 	"github.com/llifezou/fil-wallet/config"
 	"github.com/urfave/cli/v2"
-	cbg "github.com/whyrusleeping/cbor-gen"
+	"golang.org/x/crypto/blake2b"
 	"golang.org/x/xerrors"
-	"reflect"
+	"os"
 	"sort"
 	"strconv"
+	"strings"
 	"text/tabwriter"
 	"time"
 )
@@ -64,6 +64,11 @@ var multisigCmd = &cli.Command{
 			Usage: "specify the nonce to use",
 			Value: 0,
 		},
+		overridePolicyFlag,
+		yesFlag,
+		memoFlag,
+		maxFeeFlag,
+		nonceOffsetFlag,
 		&cli.StringFlag{
 			Name:  "type",
 			Usage: "wallet type, ps: secp256k1, bls",
@@ -83,6 +88,8 @@ var multisigCmd = &cli.Command{
 	Subcommands: []*cli.Command{
 		msigCreateCmd,
 		msigInspectCmd,
+		msigVestingCmd,
+		msigWatchCmd,
 		msigProposeCmd,
 		msigRemoveProposeCmd,
 		msigApproveCmd,
@@ -111,8 +118,14 @@ var multisigCmd = &cli.Command{
 		msigConfirmChangeWorkerApproveCmd,
 		msigSetControlProposeCmd,
 		msigSetControlApproveCmd,
+		msigSetPeerIDProposeCmd,
+		msigSetPeerIDApproveCmd,
+		msigSetAddrsProposeCmd,
+		msigSetAddrsApproveCmd,
 		msigProposeChangeBeneficiary,
 		msigConfirmChangeBeneficiary,
+		msigSignBundleCmd,
+		msigPushBundleCmd,
 	},
 	Before: func(c *cli.Context) error {
 		config.InitConfig(c.String("conf-path"))
@@ -139,6 +152,10 @@ var msigCreateCmd = &cli.Command{
 			Usage: "length of the period over which funds unlock",
 			Value: "0",
 		},
+		&cli.Int64Flag{
+			Name:  "start-epoch",
+			Usage: "epoch the funds start to unlock, the current head by default when --duration is set",
+		},
 		&cli.StringFlag{
 			Name:  "from",
 			Usage: "account to send the create message from",
@@ -181,6 +198,18 @@ var msigCreateCmd = &cli.Command{
 
 		d := abi.ChainEpoch(cctx.Uint64("duration"))
 
+		// an unlock starting at epoch 0 is long over, the vesting starts now unless told otherwise
+		start := abi.ChainEpoch(cctx.Int64("start-epoch"))
+		if !cctx.IsSet("start-epoch") && d > 0 {
+			conf := config.Conf()
+			height, err := client.LotusChainHead(conf.Chain.RpcAddr, conf.Chain.Token)
+			if err != nil {
+				return err
+			}
+			start = abi.ChainEpoch(height)
+			fmt.Printf("funds unlock from epoch %d to %d\n", start, start+d)
+		}
+
 		gp := types.NewInt(1)
 
 		nk, err := getAccount(cctx)
@@ -189,12 +218,12 @@ var msigCreateCmd = &cli.Command{
 		}
 
 		msiger := NewMsiger()
-		proto, err := msiger.MsigCreate(required, addrs, d, intVal, sendAddr, gp)
+		proto, err := msiger.MsigCreate(required, addrs, start, d, intVal, sendAddr, gp)
 		if err != nil {
 			return err
 		}
 
-		msgCid, err := send(nk, proto)
+		msgCid, err := send(cctx, nk, proto)
 		if err != nil {
 			log.Error(err)
 			return err
@@ -227,6 +256,7 @@ var msigCreateCmd = &cli.Command{
 			break
 		}
 
+		journalConfirmed(msgCid.String(), wait)
 		if wait.Receipt.ExitCode != 0 {
 			return fmt.Errorf("msg returned exit %d", wait.Receipt.ExitCode)
 		}
@@ -254,6 +284,10 @@ var msigInspectCmd = &cli.Command{
 			Name:  "decode-params",
 			Usage: "Decode parameters of transaction proposals",
 		},
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "Print the multisig as json, always with the vesting details",
+		},
 	},
 	Action: func(cctx *cli.Context) error {
 		if !cctx.Args().Present() {
@@ -268,150 +302,261 @@ var msigInspectCmd = &cli.Command{
 		defer closer()
 		ctx := context.Background()
 
-		store := adt.WrapStore(ctx, cbor.NewCborStore(blockstore.NewAPIBlockstore(api)))
-
 		maddr, err := address.NewFromString(cctx.Args().First())
 		if err != nil {
 			return err
 		}
 
-		head, err := api.ChainHead(ctx)
-		if err != nil {
-			return err
-		}
-
-		act, err := api.StateGetActor(ctx, maddr, head.Key())
+		info, err := inspectMsig(ctx, api, maddr, cctx.Bool("decode-params"))
 		if err != nil {
 			return err
 		}
 
-		ownId, err := api.StateLookupID(ctx, maddr, types.EmptyTSK)
-		if err != nil {
-			return err
-		}
+		if cctx.Bool("json") {
+			b, err := json.MarshalIndent(info, "", "  ")
+			if err != nil {
+				return err
+			}
 
-		mstate, err := multisig.Load(store, act)
-		if err != nil {
-			return err
-		}
-		locked, err := mstate.LockedBalance(head.Height())
-		if err != nil {
-			return err
+			fmt.Fprintln(cctx.App.Writer, string(b))
+			return nil
 		}
 
-		fmt.Fprintf(cctx.App.Writer, "Balance: %s\n", types.FIL(act.Balance))
-		fmt.Fprintf(cctx.App.Writer, "Spendable: %s\n", types.FIL(types.BigSub(act.Balance, locked)))
+		fmt.Fprintf(cctx.App.Writer, "Balance: %s\n", info.Balance)
+		fmt.Fprintf(cctx.App.Writer, "Spendable: %s\n", info.Spendable)
 
 		if cctx.Bool("vesting") {
-			ib, err := mstate.InitialBalance()
-			if err != nil {
-				return err
-			}
-			fmt.Fprintf(cctx.App.Writer, "InitialBalance: %s\n", types.FIL(ib))
-			se, err := mstate.StartEpoch()
-			if err != nil {
-				return err
-			}
-			fmt.Fprintf(cctx.App.Writer, "StartEpoch: %d\n", se)
-			ud, err := mstate.UnlockDuration()
-			if err != nil {
-				return err
-			}
-			fmt.Fprintf(cctx.App.Writer, "UnlockDuration: %d\n", ud)
+			fmt.Fprintf(cctx.App.Writer, "InitialBalance: %s\n", info.Vesting.InitialBalance)
+			fmt.Fprintf(cctx.App.Writer, "Locked: %s\n", info.Vesting.Locked)
+			fmt.Fprintf(cctx.App.Writer, "StartEpoch: %d\n", info.Vesting.StartEpoch)
+			fmt.Fprintf(cctx.App.Writer, "UnlockDuration: %d\n", info.Vesting.UnlockDuration)
+			fmt.Fprintf(cctx.App.Writer, "UnlockEpoch: %d\n", info.Vesting.UnlockEpoch)
 		}
 
-		signers, err := mstate.Signers()
-		if err != nil {
-			return err
-		}
-		threshold, err := mstate.Threshold()
-		if err != nil {
-			return err
-		}
-		fmt.Fprintf(cctx.App.Writer, "Threshold: %d / %d\n", threshold, len(signers))
+		fmt.Fprintf(cctx.App.Writer, "Threshold: %d / %d\n", info.Threshold, len(info.Signers))
 		fmt.Fprintln(cctx.App.Writer, "Signers:")
 
 		signerTable := tabwriter.NewWriter(cctx.App.Writer, 8, 4, 2, ' ', 0)
 		fmt.Fprintf(signerTable, "ID\tAddress\n")
-		for _, s := range signers {
-			signerActor, err := api.StateAccountKey(ctx, s, types.EmptyTSK)
-			if err != nil {
-				fmt.Fprintf(signerTable, "%s\t%s\n", s, "N/A")
-			} else {
-				fmt.Fprintf(signerTable, "%s\t%s\n", s, signerActor)
-			}
+		for _, s := range info.Signers {
+			fmt.Fprintf(signerTable, "%s\t%s\n", s.ID, s.Address)
 		}
 		if err := signerTable.Flush(); err != nil {
 			return xerrors.Errorf("flushing output: %+v", err)
 		}
 
-		pending := make(map[int64]multisig.Transaction)
-		if err := mstate.ForEachPendingTxn(func(id int64, txn multisig.Transaction) error {
-			pending[id] = txn
-			return nil
-		}); err != nil {
-			return xerrors.Errorf("reading pending transactions: %w", err)
-		}
-
-		decParams := cctx.Bool("decode-params")
-		fmt.Fprintln(cctx.App.Writer, "Transactions: ", len(pending))
-		if len(pending) > 0 {
-			var txids []int64
-			for txid := range pending {
-				txids = append(txids, txid)
-			}
-			sort.Slice(txids, func(i, j int) bool {
-				return txids[i] < txids[j]
-			})
-
+		fmt.Fprintln(cctx.App.Writer, "Transactions: ", len(info.Transactions))
+		if len(info.Transactions) > 0 {
 			w := tabwriter.NewWriter(cctx.App.Writer, 8, 4, 2, ' ', 0)
-			fmt.Fprintf(w, "ID\tState\tApprovals\tTo\tValue\tMethod\tParams\n")
-			for _, txid := range txids {
-				tx := pending[txid]
-				target := tx.To.String()
-				if tx.To == ownId {
-					target += " (self)"
+			fmt.Fprintf(w, "ID\tState\tApprovals\tApproved\tTo\tValue\tMethod\tProposalHash\tParams\n")
+			for _, tx := range info.Transactions {
+				var approved []string
+				for _, a := range tx.Approved {
+					approved = append(approved, a.Address)
 				}
-				targAct, err := api.StateGetActor(ctx, tx.To, types.EmptyTSK)
-				paramStr := fmt.Sprintf("%x", tx.Params)
 
-				if err != nil {
-					if tx.Method == 0 {
-						fmt.Fprintf(w, "%d\t%s\t%d\t%s\t%s\t%s(%d)\t%s\n", txid, "pending", len(tx.Approved), target, types.FIL(tx.Value), "Send", tx.Method, paramStr)
-					} else {
-						fmt.Fprintf(w, "%d\t%s\t%d\t%s\t%s\t%s(%d)\t%s\n", txid, "pending", len(tx.Approved), target, types.FIL(tx.Value), "new account, unknown method", tx.Method, paramStr)
-					}
-				} else {
-					// todo 反解params
-					method := consensus.NewActorRegistry().Methods[targAct.Code][tx.Method] // TODO: use remote map
-
-					if decParams && tx.Method != 0 {
-						ptyp := reflect.New(method.Params.Elem()).Interface().(cbg.CBORUnmarshaler)
-						if err := ptyp.UnmarshalCBOR(bytes.NewReader(tx.Params)); err != nil {
-							return xerrors.Errorf("failed to decode parameters of transaction %d: %w", txid, err)
-						}
-
-						b, err := json.Marshal(ptyp)
-						if err != nil {
-							return xerrors.Errorf("could not json marshal parameter type: %w", err)
-						}
-
-						paramStr = string(b)
-					}
-
-					fmt.Fprintf(w, "%d\t%s\t%d\t%s\t%s\t%s(%d)\t%s\n", txid, "pending", len(tx.Approved), target, types.FIL(tx.Value), method.Name, tx.Method, paramStr)
-				}
+				fmt.Fprintf(w, "%d\t%s\t%d\t%s\t%s\t%s\t%s(%d)\t%s\t%s\n", tx.ID, tx.State, len(tx.Approved), strings.Join(approved, ","), tx.To, tx.Value, tx.Method, tx.MethodNum, tx.ProposalHash, tx.Params)
 			}
 			if err := w.Flush(); err != nil {
 				return xerrors.Errorf("flushing output: %+v", err)
 			}
-
 		}
 
 		return nil
 	},
 }
 
+type msigInfo struct {
+	Address      string        `json:"address"`
+	Balance      types.FIL     `json:"balance"`
+	Spendable    types.FIL     `json:"spendable"`
+	Vesting      msigVesting   `json:"vesting"`
+	Threshold    uint64        `json:"threshold"`
+	Signers      []msigSigner  `json:"signers"`
+	Transactions []msigPending `json:"transactions"`
+}
+
+type msigVesting struct {
+	InitialBalance types.FIL `json:"initialBalance"`
+	// Locked is the balance still locked at the current epoch
+	Locked         types.FIL      `json:"locked"`
+	StartEpoch     abi.ChainEpoch `json:"startEpoch"`
+	UnlockDuration abi.ChainEpoch `json:"unlockDuration"`
+	// UnlockEpoch is the epoch the whole initial balance is unlocked
+	UnlockEpoch abi.ChainEpoch `json:"unlockEpoch"`
+}
+
+type msigSigner struct {
+	ID string `json:"id"`
+	// Address is the key address of the signer, N/A when it can't be resolved
+	Address string `json:"address"`
+}
+
+type msigPending struct {
+	ID        int64         `json:"id"`
+	State     string        `json:"state"`
+	Approved  []msigSigner  `json:"approved"`
+	To        string        `json:"to"`
+	Value     types.FIL     `json:"value"`
+	Method    string        `json:"method"`
+	MethodNum abi.MethodNum `json:"methodNum"`
+	// Params are hex, or json when decoded
+	Params string `json:"params"`
+	// ProposalHash is the hash approve and cancel pass to make sure they act on this transaction
+	ProposalHash string `json:"proposalHash"`
+}
+
+// inspectMsig reads the state of the multisig at the head, the ids of the signers and approvers are
+// resolved to their key addresses
+func inspectMsig(ctx context.Context, api api.FullNode, maddr address.Address, decodeParams bool) (*msigInfo, error) {
+	store := adt.WrapStore(ctx, cbor.NewCborStore(blockstore.NewAPIBlockstore(api)))
+
+	head, err := api.ChainHead(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	act, err := api.StateGetActor(ctx, maddr, head.Key())
+	if err != nil {
+		return nil, err
+	}
+
+	ownId, err := api.StateLookupID(ctx, maddr, types.EmptyTSK)
+	if err != nil {
+		return nil, err
+	}
+
+	mstate, err := multisig.Load(store, act)
+	if err != nil {
+		return nil, err
+	}
+	locked, err := mstate.LockedBalance(head.Height())
+	if err != nil {
+		return nil, err
+	}
+
+	info := &msigInfo{
+		Address:   maddr.String(),
+		Balance:   types.FIL(act.Balance),
+		Spendable: types.FIL(types.BigSub(act.Balance, locked)),
+	}
+
+	ib, err := mstate.InitialBalance()
+	if err != nil {
+		return nil, err
+	}
+	se, err := mstate.StartEpoch()
+	if err != nil {
+		return nil, err
+	}
+	ud, err := mstate.UnlockDuration()
+	if err != nil {
+		return nil, err
+	}
+	info.Vesting = msigVesting{
+		InitialBalance: types.FIL(ib),
+		Locked:         types.FIL(locked),
+		StartEpoch:     se,
+		UnlockDuration: ud,
+		UnlockEpoch:    se + ud,
+	}
+
+	if info.Threshold, err = mstate.Threshold(); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[address.Address]string)
+	resolve := func(id address.Address) msigSigner {
+		if k, ok := keys[id]; ok {
+			return msigSigner{ID: id.String(), Address: k}
+		}
+
+		k := "N/A"
+		if ka, err := api.StateAccountKey(ctx, id, types.EmptyTSK); err == nil {
+			k = ka.String()
+		}
+		keys[id] = k
+		return msigSigner{ID: id.String(), Address: k}
+	}
+
+	signers, err := mstate.Signers()
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range signers {
+		info.Signers = append(info.Signers, resolve(s))
+	}
+
+	pending := make(map[int64]multisig.Transaction)
+	if err := mstate.ForEachPendingTxn(func(id int64, txn multisig.Transaction) error {
+		pending[id] = txn
+		return nil
+	}); err != nil {
+		return nil, xerrors.Errorf("reading pending transactions: %w", err)
+	}
+
+	var txids []int64
+	for txid := range pending {
+		txids = append(txids, txid)
+	}
+	sort.Slice(txids, func(i, j int) bool {
+		return txids[i] < txids[j]
+	})
+
+	for _, txid := range txids {
+		tx := pending[txid]
+		p := msigPending{
+			ID:        txid,
+			State:     "pending",
+			To:        tx.To.String(),
+			Value:     types.FIL(tx.Value),
+			MethodNum: tx.Method,
+			Method:    "Send",
+			Params:    fmt.Sprintf("%x", tx.Params),
+		}
+		if tx.To == ownId {
+			p.To += " (self)"
+		}
+		for _, a := range tx.Approved {
+			p.Approved = append(p.Approved, resolve(a))
+		}
+
+		targAct, err := api.StateGetActor(ctx, tx.To, types.EmptyTSK)
+		if err != nil {
+			// the target is a new account, only Send can be called on it
+			if tx.Method != builtin.MethodSend {
+				p.Method = "new account, unknown method"
+			}
+		} else {
+			var params string
+			p.Method, params = methodInfo(targAct.Code, tx.Method, tx.Params)
+			if decodeParams {
+				p.Params = params
+			}
+		}
+
+		if len(tx.Approved) > 0 {
+			hashData := multisig.ProposalHashData{
+				Requester: tx.Approved[0],
+				To:        tx.To,
+				Value:     tx.Value,
+				Method:    tx.Method,
+				Params:    tx.Params,
+			}
+			b, err := hashData.Serialize()
+			if err != nil {
+				return nil, xerrors.Errorf("serializing the proposal hash data of transaction %d: %w", txid, err)
+			}
+			h := blake2b.Sum256(b)
+			p.ProposalHash = hex.EncodeToString(h[:])
+		}
+
+		info.Transactions = append(info.Transactions, p)
+	}
+
+	return info, nil
+}
+
 var msigProposeCmd = &cli.Command{
 	Name:      "propose",
 	Usage:     "Propose a multisig transaction",
@@ -421,6 +566,10 @@ var msigProposeCmd = &cli.Command{
 			Name:  "from",
 			Usage: "account to send the propose message from",
 		},
+		&cli.StringFlag{
+			Name:  "export",
+			Usage: "write a proposal bundle for the signers on air-gapped machines to this file",
+		},
 	},
 	Action: func(cctx *cli.Context) error {
 		if cctx.Args().Len() < 3 {
@@ -495,7 +644,7 @@ var msigProposeCmd = &cli.Command{
 			return err
 		}
 
-		msgCid, err := send(nk, proto)
+		msgCid, err := send(cctx, nk, proto)
 		if err != nil {
 			log.Error(err)
 			return err
@@ -504,21 +653,193 @@ var msigProposeCmd = &cli.Command{
 		fmt.Println("sent proposal in message: ", msgCid)
 		fmt.Println(fmt.Sprintf("%s%s", config.Conf().Chain.Explorer, msgCid.String()))
 
+		if cctx.IsSet("export") {
+			return exportBundle(cctx.String("export"), msgCid.String(), proto.From, msig, dest, types.BigInt(value), abi.MethodNum(method), params)
+		}
+
 		return waitProposalMsg(msgCid.String())
 	},
 }
 
+var msigExpectFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:  "expect-to",
+		Usage: "abort unless the pending transaction sends to this address",
+	},
+	&cli.StringFlag{
+		Name:  "expect-value",
+		Usage: "abort unless the pending transaction sends this value, e.g. 10FIL",
+	},
+	&cli.StringFlag{
+		Name:  "expect-method",
+		Usage: "abort unless the pending transaction calls this method, a number or a name like Send",
+	},
+}
+
+// loadPendingTxn reads the pending transaction from the state of the multisig at the head
+func loadPendingTxn(ctx context.Context, api api.FullNode, maddr address.Address, txid uint64) (*multisig.Transaction, error) {
+	act, err := api.StateGetActor(ctx, maddr, types.EmptyTSK)
+	if err != nil {
+		return nil, err
+	}
+
+	store := adt.WrapStore(ctx, cbor.NewCborStore(blockstore.NewAPIBlockstore(api)))
+	mstate, err := multisig.Load(store, act)
+	if err != nil {
+		return nil, err
+	}
+
+	var found *multisig.Transaction
+	if err := mstate.ForEachPendingTxn(func(id int64, txn multisig.Transaction) error {
+		if id == int64(txid) {
+			found = &txn
+		}
+		return nil
+	}); err != nil {
+		return nil, xerrors.Errorf("reading pending transactions: %w", err)
+	}
+	if found == nil {
+		return nil, xerrors.Errorf("multisig %s has no pending transaction %d", maddr, txid)
+	}
+	if len(found.Approved) == 0 {
+		return nil, xerrors.Errorf("pending transaction %d has no proposer", txid)
+	}
+
+	return found, nil
+}
+
+// msigApproveReviewed approves the pending transaction as it is in the state: its contents are
+// printed, checked against the --expect-* flags and bound to the approval with the proposal hash,
+// so the approval fails if the transaction under the id is not the one reviewed
+func msigApproveReviewed(cctx *cli.Context, maddr address.Address, txid uint64, from address.Address) (*types.Message, error) {
+	tx, err := reviewPendingTxn(cctx, maddr, txid)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewMsiger().MsigApproveTxnHash(maddr, txid, tx.Approved[0], tx.To, types.BigInt(tx.Value), from, uint64(tx.Method), tx.Params)
+}
+
+// msigCancelReviewed cancels the pending transaction as it is in the state, like msigApproveReviewed
+func msigCancelReviewed(cctx *cli.Context, maddr address.Address, txid uint64, from address.Address) (*types.Message, error) {
+	tx, err := reviewPendingTxn(cctx, maddr, txid)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewMsiger().MsigCancelTxnHash(maddr, txid, tx.To, types.BigInt(tx.Value), from, uint64(tx.Method), tx.Params)
+}
+
+// reviewPendingTxn prints the pending transaction with its decoded params and checks it against the
+// --expect-* flags, before an approve or cancel message is built for it
+func reviewPendingTxn(cctx *cli.Context, maddr address.Address, txid uint64) (*multisig.Transaction, error) {
+	conf := config.Conf()
+	api, closer, err := client.NewLotusAPI(conf.Chain.RpcAddr, conf.Chain.Token)
+	if err != nil {
+		return nil, err
+	}
+	defer closer()
+	ctx := context.Background()
+
+	tx, err := loadPendingTxn(ctx, api, maddr, txid)
+	if err != nil {
+		return nil, err
+	}
+
+	method, params := "Send", fmt.Sprintf("%x", tx.Params)
+	if targAct, err := api.StateGetActor(ctx, tx.To, types.EmptyTSK); err != nil {
+		if tx.Method != builtin.MethodSend {
+			method = "new account, unknown method"
+		}
+	} else {
+		method, params = methodInfo(targAct.Code, tx.Method, tx.Params)
+	}
+
+	fmt.Printf("Pending transaction %d of %s:\n", txid, maddr)
+	w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "Proposer:\t%s\n", tx.Approved[0])
+	fmt.Fprintf(w, "Approvals:\t%d\n", len(tx.Approved))
+	fmt.Fprintf(w, "To:\t%s\n", tx.To)
+	fmt.Fprintf(w, "Value:\t%s\n", types.FIL(tx.Value))
+	fmt.Fprintf(w, "Method:\t%s (%d)\n", method, tx.Method)
+	fmt.Fprintf(w, "Params:\t%s\n", params)
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+	fmt.Println()
+
+	if err := checkExpectedTxn(cctx, api, tx, method); err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// checkExpectedTxn aborts when the pending transaction doesn't match the --expect-* flags
+func checkExpectedTxn(cctx *cli.Context, api api.FullNode, tx *multisig.Transaction, method string) error {
+	if s := cctx.String("expect-to"); s != "" {
+		to, err := address.NewFromString(s)
+		if err != nil {
+			return xerrors.Errorf("--expect-to: %w", err)
+		}
+
+		if to != tx.To {
+			// the proposal may use the id or the robust address of the destination
+			toID, err := api.StateLookupID(context.Background(), to, types.EmptyTSK)
+			txID, err2 := api.StateLookupID(context.Background(), tx.To, types.EmptyTSK)
+			if err != nil || err2 != nil || toID != txID {
+				return xerrors.Errorf("pending transaction sends to %s, expected %s", tx.To, to)
+			}
+		}
+	}
+
+	if s := cctx.String("expect-value"); s != "" {
+		v, err := types.ParseFIL(s)
+		if err != nil {
+			return xerrors.Errorf("--expect-value: %w", err)
+		}
+		if !abi.TokenAmount(v).Equals(tx.Value) {
+			return xerrors.Errorf("pending transaction sends %s, expected %s", types.FIL(tx.Value), v)
+		}
+	}
+
+	if s := cctx.String("expect-method"); s != "" {
+		if s != method && s != fmt.Sprint(uint64(tx.Method)) {
+			return xerrors.Errorf("pending transaction calls %s (%d), expected %s", method, tx.Method, s)
+		}
+	}
+
+	return nil
+}
+
 var msigApproveCmd = &cli.Command{
 	Name:      "approve",
-	Usage:     "Approve a multisig message",
+	Aliases:   []string{"approve-any"},
+	Usage:     "Approve a multisig message, e.g. one proposed with --via-msig, after printing it decoded",
 	ArgsUsage: "<multisigAddress txId> [proposerAddress destination value [methodId methodParams]]",
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		&cli.StringFlag{
 			Name:  "from",
-			Usage: "account to send the approve message from",
+			Usage: "account to send the approve message from, the wallet address by default",
 		},
-	},
+		&cli.StringFlag{
+			Name:  "bundle",
+			Usage: "build the unsigned approve messages of the --signer addresses into the proposal bundle, to sign offline",
+		},
+		&cli.StringSliceFlag{
+			Name:  "signer",
+			Usage: "signer to build an approve message for, with --bundle",
+		},
+		&cli.StringFlag{
+			Name:  "out",
+			Usage: "write the bundle with the approve messages to this file instead of updating --bundle",
+		},
+	}, msigExpectFlags...),
 	Action: func(cctx *cli.Context) error {
+		if cctx.IsSet("bundle") {
+			return approveBundle(cctx)
+		}
+
 		if cctx.Args().Len() < 2 {
 			return fmt.Errorf("must pass at least multisig address and message ID")
 		}
@@ -541,28 +862,29 @@ var msigApproveCmd = &cli.Command{
 			return err
 		}
 
-		var from address.Address
-		f, err := address.NewFromString(cctx.String("from"))
+		nk, err := getAccount(cctx)
 		if err != nil {
 			return err
 		}
-		from = f
 
-		nk, err := getAccount(cctx)
-		if err != nil {
-			return err
+		from := nk.Address
+		if cctx.IsSet("from") {
+			from, err = address.NewFromString(cctx.String("from"))
+			if err != nil {
+				return err
+			}
 		}
 
 		msiger := NewMsiger()
 
 		var msgCid cid.Cid
 		if cctx.Args().Len() == 2 {
-			proto, err := msiger.MsigApprove(msig, txid, from)
+			proto, err := msigApproveReviewed(cctx, msig, txid, from)
 			if err != nil {
 				return err
 			}
 
-			msgCid, err = send(nk, proto)
+			msgCid, err = send(cctx, nk, proto)
 			if err != nil {
 				log.Error(err)
 				return err
@@ -610,12 +932,16 @@ var msigApproveCmd = &cli.Command{
 				params = p
 			}
 
+			if _, err := reviewPendingTxn(cctx, msig, txid); err != nil {
+				return err
+			}
+
 			proto, err := msiger.MsigApproveTxnHash(msig, txid, proposer, dest, types.BigInt(value), from, method, params)
 			if err != nil {
 				return err
 			}
 
-			msgCid, err = send(nk, proto)
+			msgCid, err = send(cctx, nk, proto)
 			if err != nil {
 				log.Error(err)
 				return err
@@ -695,7 +1021,7 @@ var msigTransferProposeCmd = &cli.Command{
 			return err
 		}
 
-		msgCid, err := send(nk, proto)
+		msgCid, err := send(cctx, nk, proto)
 		if err != nil {
 			log.Error(err)
 			return err
@@ -712,12 +1038,12 @@ var msigTransferApproveCmd = &cli.Command{
 	Name:      "transfer-approve",
 	Usage:     "Approve a multisig message",
 	ArgsUsage: "<multisigAddress txId>",
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		&cli.StringFlag{
 			Name:  "from",
 			Usage: "account to send the approve message from",
 		},
-	},
+	}, msigExpectFlags...),
 	Action: func(cctx *cli.Context) error {
 		if cctx.Args().Len() != 2 {
 			return fmt.Errorf("must have multisig address and message ID")
@@ -745,14 +1071,12 @@ var msigTransferApproveCmd = &cli.Command{
 			return err
 		}
 
-		msiger := NewMsiger()
-
-		proto, err := msiger.MsigApprove(msig, txid, from)
+		proto, err := msigApproveReviewed(cctx, msig, txid, from)
 		if err != nil {
 			return err
 		}
 
-		msgCid, err := send(nk, proto)
+		msgCid, err := send(cctx, nk, proto)
 		if err != nil {
 			log.Error(err)
 			return err
@@ -769,12 +1093,12 @@ var msigTransferCancelCmd = &cli.Command{
 	Name:      "transfer-cancel",
 	Usage:     "Cancel transfer multisig message",
 	ArgsUsage: "<multisigAddress txId> [destination value [methodId methodParams]]",
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		&cli.StringFlag{
 			Name:  "from",
 			Usage: "account to send the cancel message from",
 		},
-	},
+	}, msigExpectFlags...),
 	Action: func(cctx *cli.Context) error {
 		if cctx.Args().Len() != 2 {
 			return fmt.Errorf("must have multisig address and txId")
@@ -802,14 +1126,12 @@ var msigTransferCancelCmd = &cli.Command{
 			return err
 		}
 
-		msiger := NewMsiger()
-
-		proto, err := msiger.MsigCancel(msig, txid, from)
+		proto, err := msigCancelReviewed(cctx, msig, txid, from)
 		if err != nil {
 			return err
 		}
 
-		msgCid, err := send(nk, proto)
+		msgCid, err := send(cctx, nk, proto)
 		if err != nil {
 			log.Error(err)
 			return err
@@ -826,12 +1148,12 @@ var msigCancelCmd = &cli.Command{
 	Name:      "cancel",
 	Usage:     "Cancel a multisig message",
 	ArgsUsage: "<multisigAddress txId> [destination value [methodId methodParams]]",
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		&cli.StringFlag{
 			Name:  "from",
 			Usage: "account to send the cancel message from",
 		},
-	},
+	}, msigExpectFlags...),
 	Action: func(cctx *cli.Context) error {
 		if cctx.Args().Len() < 2 {
 			return fmt.Errorf("must pass at least multisig address and message ID")
@@ -871,12 +1193,12 @@ var msigCancelCmd = &cli.Command{
 
 		var msgCid cid.Cid
 		if cctx.Args().Len() == 2 {
-			proto, err := msiger.MsigCancel(msig, txid, from)
+			proto, err := msigCancelReviewed(cctx, msig, txid, from)
 			if err != nil {
 				return err
 			}
 
-			msgCid, err = send(nk, proto)
+			msgCid, err = send(cctx, nk, proto)
 			if err != nil {
 				log.Error(err)
 				return err
@@ -908,12 +1230,16 @@ var msigCancelCmd = &cli.Command{
 				params = p
 			}
 
+			if _, err := reviewPendingTxn(cctx, msig, txid); err != nil {
+				return err
+			}
+
 			proto, err := msiger.MsigCancelTxnHash(msig, txid, dest, types.BigInt(value), from, method, params)
 			if err != nil {
 				return err
 			}
 
-			msgCid, err = send(nk, proto)
+			msgCid, err = send(cctx, nk, proto)
 			if err != nil {
 				log.Error(err)
 				return err
@@ -976,7 +1302,7 @@ var msigRemoveProposeCmd = &cli.Command{
 			return err
 		}
 
-		msgCid, err := send(nk, proto)
+		msgCid, err := send(cctx, nk, proto)
 		if err != nil {
 			log.Error(err)
 			return err
@@ -1038,7 +1364,7 @@ var msigAddProposeCmd = &cli.Command{
 			return err
 		}
 
-		msgCid, err := send(nk, proto)
+		msgCid, err := send(cctx, nk, proto)
 		if err != nil {
 			log.Error(err)
 			return err
@@ -1055,12 +1381,12 @@ var msigAddApproveCmd = &cli.Command{
 	Name:      "add-approve",
 	Usage:     "Approve a message to add a signer",
 	ArgsUsage: "[multisigAddress proposerAddress txId newAddress increaseThreshold]",
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		&cli.StringFlag{
 			Name:  "from",
 			Usage: "account to send the approve message from",
 		},
-	},
+	}, msigExpectFlags...),
 	Action: func(cctx *cli.Context) error {
 		if cctx.Args().Len() != 5 {
 			return fmt.Errorf("must pass multisig address, proposer address, transaction id, new signer address, whether to increase threshold")
@@ -1106,12 +1432,16 @@ var msigAddApproveCmd = &cli.Command{
 
 		msiger := NewMsiger()
 
+		if _, err := reviewPendingTxn(cctx, msig, txid); err != nil {
+			return err
+		}
+
 		proto, err := msiger.MsigAddApprove(msig, from, txid, prop, newAdd, inc)
 		if err != nil {
 			return err
 		}
 
-		msgCid, err := send(nk, proto)
+		msgCid, err := send(cctx, nk, proto)
 		if err != nil {
 			log.Error(err)
 			return err
@@ -1179,7 +1509,7 @@ var msigAddCancelCmd = &cli.Command{
 			return err
 		}
 
-		msgCid, err := send(nk, proto)
+		msgCid, err := send(cctx, nk, proto)
 		if err != nil {
 			log.Error(err)
 			return err
@@ -1242,7 +1572,7 @@ var msigSwapProposeCmd = &cli.Command{
 			return err
 		}
 
-		msgCid, err := send(nk, proto)
+		msgCid, err := send(cctx, nk, proto)
 		if err != nil {
 			log.Error(err)
 			return err
@@ -1258,12 +1588,12 @@ var msigSwapApproveCmd = &cli.Command{
 	Name:      "swap-approve",
 	Usage:     "Approve a message to swap signers",
 	ArgsUsage: "[multisigAddress proposerAddress txId oldAddress newAddress]",
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		&cli.StringFlag{
 			Name:  "from",
 			Usage: "account to send the approve message from",
 		},
-	},
+	}, msigExpectFlags...),
 	Action: func(cctx *cli.Context) error {
 		if cctx.Args().Len() != 5 {
 			return fmt.Errorf("must pass multisig address, proposer address, transaction id, old signer address, new signer address")
@@ -1309,12 +1639,16 @@ var msigSwapApproveCmd = &cli.Command{
 
 		msiger := NewMsiger()
 
+		if _, err := reviewPendingTxn(cctx, msig, txid); err != nil {
+			return err
+		}
+
 		proto, err := msiger.MsigSwapApprove(msig, from, txid, prop, oldAdd, newAdd)
 		if err != nil {
 			return err
 		}
 
-		msgCid, err := send(nk, proto)
+		msgCid, err := send(cctx, nk, proto)
 		if err != nil {
 			log.Error(err)
 			return err
@@ -1382,7 +1716,7 @@ var msigSwapCancelCmd = &cli.Command{
 			return err
 		}
 
-		msgCid, err := send(nk, proto)
+		msgCid, err := send(cctx, nk, proto)
 		if err != nil {
 			log.Error(err)
 			return err
@@ -1460,7 +1794,7 @@ var msigLockProposeCmd = &cli.Command{
 			return err
 		}
 
-		msgCid, err := send(nk, proto)
+		msgCid, err := send(cctx, nk, proto)
 		if err != nil {
 			log.Error(err)
 			return err
@@ -1476,12 +1810,12 @@ var msigLockApproveCmd = &cli.Command{
 	Name:      "lock-approve",
 	Usage:     "Approve a message to lock up some balance",
 	ArgsUsage: "[multisigAddress proposerAddress txId startEpoch unlockDuration amount]",
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		&cli.StringFlag{
 			Name:  "from",
 			Usage: "account to send the approve message from",
 		},
-	},
+	}, msigExpectFlags...),
 	Action: func(cctx *cli.Context) error {
 		if cctx.Args().Len() != 6 {
 			return fmt.Errorf("must pass multisig address, proposer address, tx id, start epoch, unlock duration, and amount")
@@ -1542,12 +1876,16 @@ var msigLockApproveCmd = &cli.Command{
 			return actErr
 		}
 
-		proto, err := msiger.MsigApproveTxnHash(msig, txid, prop, msig, big.Zero(), from, uint64(multisig.Methods.LockBalance), params)
-		if err != nil {
+		if _, err := reviewPendingTxn(cctx, msig, txid); err != nil {
 			return err
 		}
 
-		msgCid, err := send(nk, proto)
+		proto, err := msiger.MsigApproveTxnHash(msig, txid, prop, msig, big.Zero(), from, uint64(multisig.Methods.LockBalance), params)
+		if err != nil {
+			return err
+		}
+
+		msgCid, err := send(cctx, nk, proto)
 		if err != nil {
 			log.Error(err)
 			return err
@@ -1629,7 +1967,7 @@ var msigLockCancelCmd = &cli.Command{
 		if err != nil {
 			return err
 		}
-		msgCid, err := send(nk, proto)
+		msgCid, err := send(cctx, nk, proto)
 		if err != nil {
 			log.Error(err)
 			return err
@@ -1694,7 +2032,7 @@ var msigThresholdProposeCmd = &cli.Command{
 			return fmt.Errorf("failed to propose change of threshold: %w", err)
 		}
 
-		msgCid, err := send(nk, proto)
+		msgCid, err := send(cctx, nk, proto)
 		if err != nil {
 			log.Error(err)
 			return err
@@ -1711,12 +2049,12 @@ var msigThresholdApproveCmd = &cli.Command{
 	Name:      "approve-threshold",
 	Usage:     "Approve a message to setting a different signing threshold on the account",
 	ArgsUsage: "[multisigAddress proposerAddress txId newM]",
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		&cli.StringFlag{
 			Name:  "from",
 			Usage: "account to send the approve message from",
 		},
-	},
+	}, msigExpectFlags...),
 	Action: func(cctx *cli.Context) error {
 		if cctx.Args().Len() != 4 {
 			return fmt.Errorf("must pass multisig address, proposer address, transaction id, newM")
@@ -1765,12 +2103,16 @@ var msigThresholdApproveCmd = &cli.Command{
 			return actErr
 		}
 
+		if _, err := reviewPendingTxn(cctx, msig, txid); err != nil {
+			return err
+		}
+
 		proto, err := msiger.MsigApproveTxnHash(msig, txid, prop, msig, big.Zero(), from, uint64(multisig.Methods.ChangeNumApprovalsThreshold), params)
 		if err != nil {
 			return err
 		}
 
-		msgCid, err := send(nk, proto)
+		msgCid, err := send(cctx, nk, proto)
 		if err != nil {
 			log.Error(err)
 			return err
@@ -1819,6 +2161,51 @@ var msigWithdrawBalanceProposeCmd = &cli.Command{
 			return err
 		}
 
+		conf := config.Conf()
+		api, closer, err := client.NewLotusAPI(conf.Chain.RpcAddr, conf.Chain.Token)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := context.Background()
+
+		multisigID, err := api.StateLookupID(ctx, multisigAddr, types.EmptyTSK)
+		if err != nil {
+			return err
+		}
+
+		mi, err := api.StateMinerInfo(ctx, minerAddr, types.EmptyTSK)
+		if err != nil {
+			return xerrors.Errorf("getting miner info: %w", err)
+		}
+
+		available, err := api.StateMinerAvailableBalance(ctx, minerAddr, types.EmptyTSK)
+		if err != nil {
+			return err
+		}
+
+		if multisigID != mi.Owner && multisigID != mi.Beneficiary {
+			return xerrors.Errorf("multisig %s is neither the owner nor the beneficiary of miner %s", multisigAddr, minerAddr)
+		}
+
+		// the withdrawal is paid to the beneficiary and limited by its quota, whoever proposes it
+		if mi.Beneficiary != mi.Owner {
+			head, err := api.ChainHead(ctx)
+			if err != nil {
+				return xerrors.Errorf("failed to get the chain head: %w", err)
+			}
+
+			printBeneficiaryTerm(mi)
+			available, err = beneficiaryWithdrawLimit(mi, head.Height(), available)
+			if err != nil {
+				return err
+			}
+		}
+
+		if abi.TokenAmount(val).GreaterThan(available) {
+			return xerrors.Errorf("can't withdraw more funds than available; requested: %s; available: %s", val, types.FIL(available))
+		}
+
 		sp, err := actors.SerializeParams(&miner5.WithdrawBalanceParams{
 			AmountRequested: abi.TokenAmount(val),
 		})
@@ -1838,7 +2225,7 @@ var msigWithdrawBalanceProposeCmd = &cli.Command{
 			return xerrors.Errorf("proposing message: %w", err)
 		}
 
-		msgCid, err := send(nk, proto)
+		msgCid, err := send(cctx, nk, proto)
 		if err != nil {
 			log.Error(err)
 			return err
@@ -1854,7 +2241,7 @@ var msigWithdrawBalanceProposeCmd = &cli.Command{
 var msigWithdrawBalanceApproveCmd = &cli.Command{
 	Name:  "withdraw-approve",
 	Usage: "Approve to withdraw FIL from the miner",
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		&cli.StringFlag{
 			Name:     "from",
 			Usage:    "specify address to send message from",
@@ -1870,7 +2257,7 @@ var msigWithdrawBalanceApproveCmd = &cli.Command{
 			Usage:    "specify miner being acted upon",
 			Required: true,
 		},
-	},
+	}, msigExpectFlags...),
 	ArgsUsage: "[amount txnId proposer]",
 	Action: func(cctx *cli.Context) error {
 		if cctx.NArg() != 3 {
@@ -1911,12 +2298,16 @@ var msigWithdrawBalanceApproveCmd = &cli.Command{
 
 		msiger := NewMsiger()
 
+		if _, err := reviewPendingTxn(cctx, multisigAddr, txid); err != nil {
+			return err
+		}
+
 		proto, err := msiger.MsigApproveTxnHash(multisigAddr, txid, proposer, minerAddr, big.Zero(), sender, uint64(builtin.MethodsMiner.WithdrawBalance), sp)
 		if err != nil {
 			return xerrors.Errorf("approving message: %w", err)
 		}
 
-		msgCid, err := send(nk, proto)
+		msgCid, err := send(cctx, nk, proto)
 		if err != nil {
 			log.Error(err)
 			return err
@@ -2001,7 +2392,7 @@ var msigChangeOwnerProposeCmd = &cli.Command{
 			return xerrors.Errorf("proposing message: %w", err)
 		}
 
-		msgCid, err := send(nk, proto)
+		msgCid, err := send(cctx, nk, proto)
 		if err != nil {
 			log.Error(err)
 			return err
@@ -2017,7 +2408,7 @@ var msigChangeOwnerProposeCmd = &cli.Command{
 var msigChangeOwnerApproveCmd = &cli.Command{
 	Name:  "change-owner-approve",
 	Usage: "Approve an owner address change",
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		&cli.StringFlag{
 			Name:     "from",
 			Usage:    "specify address to send message from",
@@ -2033,7 +2424,7 @@ var msigChangeOwnerApproveCmd = &cli.Command{
 			Usage:    "specify miner being acted upon",
 			Required: true,
 		},
-	},
+	}, msigExpectFlags...),
 	ArgsUsage: "[newOwner txnId proposer]",
 	Action: func(cctx *cli.Context) error {
 		if cctx.NArg() != 3 {
@@ -2091,12 +2482,16 @@ var msigChangeOwnerApproveCmd = &cli.Command{
 
 		msiger := NewMsiger()
 
+		if _, err := reviewPendingTxn(cctx, multisigAddr, txid); err != nil {
+			return err
+		}
+
 		proto, err := msiger.MsigApproveTxnHash(multisigAddr, txid, proposer, minerAddr, big.Zero(), sender, uint64(builtin.MethodsMiner.ChangeOwnerAddress), sp)
 		if err != nil {
 			return xerrors.Errorf("approving message: %w", err)
 		}
 
-		msgCid, err := send(nk, proto)
+		msgCid, err := send(cctx, nk, proto)
 		if err != nil {
 			log.Error(err)
 			return err
@@ -2207,7 +2602,7 @@ var msigChangeWorkerProposeCmd = &cli.Command{
 			return xerrors.Errorf("proposing message: %w", err)
 		}
 
-		msgCid, err := send(nk, proto)
+		msgCid, err := send(cctx, nk, proto)
 		if err != nil {
 			log.Error(err)
 			return err
@@ -2223,7 +2618,7 @@ var msigChangeWorkerProposeCmd = &cli.Command{
 var msigChangeWorkerApproveCmd = &cli.Command{
 	Name:  "change-worker-approve",
 	Usage: "Approve an owner address change",
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		&cli.StringFlag{
 			Name:     "from",
 			Usage:    "specify address to send message from",
@@ -2239,7 +2634,7 @@ var msigChangeWorkerApproveCmd = &cli.Command{
 			Usage:    "specify miner being acted upon",
 			Required: true,
 		},
-	},
+	}, msigExpectFlags...),
 	ArgsUsage: "[newWorker txnId proposer]",
 	Action: func(cctx *cli.Context) error {
 		if cctx.NArg() != 3 {
@@ -2318,12 +2713,16 @@ var msigChangeWorkerApproveCmd = &cli.Command{
 
 		msiger := NewMsiger()
 
+		if _, err := reviewPendingTxn(cctx, multisigAddr, txid); err != nil {
+			return err
+		}
+
 		proto, err := msiger.MsigApproveTxnHash(multisigAddr, txid, proposer, minerAddr, big.Zero(), sender, uint64(builtin.MethodsMiner.ChangeWorkerAddress), sp)
 		if err != nil {
 			return xerrors.Errorf("approving message: %w", err)
 		}
 
-		msgCid, err := send(nk, proto)
+		msgCid, err := send(cctx, nk, proto)
 		if err != nil {
 			log.Error(err)
 			return err
@@ -2396,7 +2795,7 @@ var msigConfirmChangeWorkerProposeCmd = &cli.Command{
 			return xerrors.Errorf("proposing message: %w", err)
 		}
 
-		msgCid, err := send(nk, proto)
+		msgCid, err := send(cctx, nk, proto)
 		if err != nil {
 			log.Error(err)
 			return err
@@ -2412,7 +2811,7 @@ var msigConfirmChangeWorkerProposeCmd = &cli.Command{
 var msigConfirmChangeWorkerApproveCmd = &cli.Command{
 	Name:  "confirm-change-worker-approve",
 	Usage: "Confirm an worker address change",
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		&cli.StringFlag{
 			Name:     "from",
 			Usage:    "specify address to send message from",
@@ -2428,7 +2827,7 @@ var msigConfirmChangeWorkerApproveCmd = &cli.Command{
 			Usage:    "specify miner being acted upon",
 			Required: true,
 		},
-	},
+	}, msigExpectFlags...),
 	ArgsUsage: "[newWorker txnId proposer]",
 	Action: func(cctx *cli.Context) error {
 		if cctx.NArg() != 3 {
@@ -2483,12 +2882,16 @@ var msigConfirmChangeWorkerApproveCmd = &cli.Command{
 
 		msiger := NewMsiger()
 
+		if _, err := reviewPendingTxn(cctx, multisigAddr, txid); err != nil {
+			return err
+		}
+
 		proto, err := msiger.MsigApproveTxnHash(multisigAddr, txid, proposer, minerAddr, big.Zero(), sender, uint64(builtin.MethodsMiner.ConfirmChangeWorkerAddress), nil)
 		if err != nil {
 			return xerrors.Errorf("approving message: %w", err)
 		}
 
-		msgCid, err := send(nk, proto)
+		msgCid, err := send(cctx, nk, proto)
 		if err != nil {
 			log.Error(err)
 			return err
@@ -2613,7 +3016,7 @@ var msigSetControlProposeCmd = &cli.Command{
 			return xerrors.Errorf("proposing message: %w", err)
 		}
 
-		msgCid, err := send(nk, proto)
+		msgCid, err := send(cctx, nk, proto)
 		if err != nil {
 			log.Error(err)
 			return err
@@ -2629,7 +3032,7 @@ var msigSetControlProposeCmd = &cli.Command{
 var msigSetControlApproveCmd = &cli.Command{
 	Name:  "set-control-approve",
 	Usage: "set control address(-es) approve",
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		&cli.StringFlag{
 			Name:     "from",
 			Usage:    "specify address to send message from",
@@ -2645,7 +3048,7 @@ var msigSetControlApproveCmd = &cli.Command{
 			Usage:    "specify miner being acted upon",
 			Required: true,
 		},
-	},
+	}, msigExpectFlags...),
 	ArgsUsage: "[txnId proposer ...address]",
 	Action: func(cctx *cli.Context) error {
 		if cctx.NArg() == 0 {
@@ -2747,12 +3150,16 @@ var msigSetControlApproveCmd = &cli.Command{
 
 		msiger := NewMsiger()
 
+		if _, err := reviewPendingTxn(cctx, multisigAddr, txid); err != nil {
+			return err
+		}
+
 		proto, err := msiger.MsigApproveTxnHash(multisigAddr, txid, proposer, minerAddr, big.Zero(), sender, uint64(builtin.MethodsMiner.ChangeWorkerAddress), sp)
 		if err != nil {
 			return xerrors.Errorf("approving message: %w", err)
 		}
 
-		msgCid, err := send(nk, proto)
+		msgCid, err := send(cctx, nk, proto)
 		if err != nil {
 			log.Error(err)
 			return err
@@ -2765,6 +3172,287 @@ var msigSetControlApproveCmd = &cli.Command{
 	},
 }
 
+var msigSetPeerIDProposeCmd = &cli.Command{
+	Name:  "set-peer-id-propose",
+	Usage: "Propose to set the peer id of the miner",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "from",
+			Usage:    "specify address to send message from",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:     "multisig",
+			Usage:    "specify multisig that will receive the message",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:     "miner",
+			Usage:    "specify miner being acted upon",
+			Required: true,
+		},
+	},
+	ArgsUsage: "[peerId]",
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return fmt.Errorf("must pass peer id")
+		}
+
+		multisigAddr, sender, minerAddr, err := getInputs(cctx)
+		if err != nil {
+			return err
+		}
+
+		sp, err := serializePeerIDParams(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		nk, err := getAccount(cctx)
+		if err != nil {
+			return err
+		}
+
+		msiger := NewMsiger()
+
+		proto, err := msiger.MsigPropose(multisigAddr, minerAddr, big.Zero(), sender, uint64(builtin.MethodsMiner.ChangePeerID), sp)
+		if err != nil {
+			return xerrors.Errorf("proposing message: %w", err)
+		}
+
+		msgCid, err := send(cctx, nk, proto)
+		if err != nil {
+			log.Error(err)
+			return err
+		}
+
+		fmt.Fprintln(cctx.App.Writer, "set peer id propose message CID:", msgCid)
+		fmt.Println(fmt.Sprintf("%s%s", config.Conf().Chain.Explorer, msgCid.String()))
+
+		return waitProposalMsg(msgCid.String())
+	},
+}
+
+var msigSetPeerIDApproveCmd = &cli.Command{
+	Name:  "set-peer-id-approve",
+	Usage: "Approve to set the peer id of the miner",
+	Flags: append([]cli.Flag{
+		&cli.StringFlag{
+			Name:     "from",
+			Usage:    "specify address to send message from",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:     "multisig",
+			Usage:    "specify multisig that will receive the message",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:     "miner",
+			Usage:    "specify miner being acted upon",
+			Required: true,
+		},
+	}, msigExpectFlags...),
+	ArgsUsage: "[peerId txnId proposer]",
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 3 {
+			return fmt.Errorf("must pass peer id, txn Id, and proposer address")
+		}
+
+		multisigAddr, sender, minerAddr, err := getInputs(cctx)
+		if err != nil {
+			return err
+		}
+
+		sp, err := serializePeerIDParams(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		txid, err := strconv.ParseUint(cctx.Args().Get(1), 10, 64)
+		if err != nil {
+			return err
+		}
+
+		proposer, err := address.NewFromString(cctx.Args().Get(2))
+		if err != nil {
+			return err
+		}
+
+		nk, err := getAccount(cctx)
+		if err != nil {
+			return err
+		}
+
+		msiger := NewMsiger()
+
+		if _, err := reviewPendingTxn(cctx, multisigAddr, txid); err != nil {
+			return err
+		}
+
+		proto, err := msiger.MsigApproveTxnHash(multisigAddr, txid, proposer, minerAddr, big.Zero(), sender, uint64(builtin.MethodsMiner.ChangePeerID), sp)
+		if err != nil {
+			return xerrors.Errorf("approving message: %w", err)
+		}
+
+		msgCid, err := send(cctx, nk, proto)
+		if err != nil {
+			log.Error(err)
+			return err
+		}
+
+		fmt.Fprintln(cctx.App.Writer, "set peer id approve message CID:", msgCid)
+		fmt.Println(fmt.Sprintf("%s%s", config.Conf().Chain.Explorer, msgCid.String()))
+
+		return waitMsg(msgCid.String())
+	},
+}
+
+var msigSetAddrsProposeCmd = &cli.Command{
+	Name:  "set-addrs-propose",
+	Usage: "Propose to set the multiaddrs of the miner",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "from",
+			Usage:    "specify address to send message from",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:     "multisig",
+			Usage:    "specify multisig that will receive the message",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:     "miner",
+			Usage:    "specify miner being acted upon",
+			Required: true,
+		},
+		&cli.BoolFlag{
+			Name:  "unset",
+			Usage: "propose to clear the multiaddrs of the miner",
+			Value: false,
+		},
+	},
+	ArgsUsage: "[...multiaddr]",
+	Action: func(cctx *cli.Context) error {
+		unset := cctx.Bool("unset")
+		if cctx.NArg() == 0 && !unset {
+			return fmt.Errorf("must pass at least one multiaddr, or --unset to clear the addresses")
+		}
+		if cctx.NArg() > 0 && unset {
+			return fmt.Errorf("unset can only be used with no arguments")
+		}
+
+		multisigAddr, sender, minerAddr, err := getInputs(cctx)
+		if err != nil {
+			return err
+		}
+
+		sp, err := serializeMultiaddrsParams(cctx.Args().Slice())
+		if err != nil {
+			return err
+		}
+
+		nk, err := getAccount(cctx)
+		if err != nil {
+			return err
+		}
+
+		msiger := NewMsiger()
+
+		proto, err := msiger.MsigPropose(multisigAddr, minerAddr, big.Zero(), sender, uint64(builtin.MethodsMiner.ChangeMultiaddrs), sp)
+		if err != nil {
+			return xerrors.Errorf("proposing message: %w", err)
+		}
+
+		msgCid, err := send(cctx, nk, proto)
+		if err != nil {
+			log.Error(err)
+			return err
+		}
+
+		fmt.Fprintln(cctx.App.Writer, "set multiaddrs propose message CID:", msgCid)
+		fmt.Println(fmt.Sprintf("%s%s", config.Conf().Chain.Explorer, msgCid.String()))
+
+		return waitProposalMsg(msgCid.String())
+	},
+}
+
+var msigSetAddrsApproveCmd = &cli.Command{
+	Name:  "set-addrs-approve",
+	Usage: "Approve to set the multiaddrs of the miner",
+	Flags: append([]cli.Flag{
+		&cli.StringFlag{
+			Name:     "from",
+			Usage:    "specify address to send message from",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:     "multisig",
+			Usage:    "specify multisig that will receive the message",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:     "miner",
+			Usage:    "specify miner being acted upon",
+			Required: true,
+		},
+	}, msigExpectFlags...),
+	ArgsUsage: "[txnId proposer ...multiaddr]",
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() < 2 {
+			return fmt.Errorf("must have txn Id, and proposer address and ...multiaddr")
+		}
+
+		txid, err := strconv.ParseUint(cctx.Args().Get(0), 10, 64)
+		if err != nil {
+			return err
+		}
+
+		proposer, err := address.NewFromString(cctx.Args().Get(1))
+		if err != nil {
+			return err
+		}
+
+		multisigAddr, sender, minerAddr, err := getInputs(cctx)
+		if err != nil {
+			return err
+		}
+
+		sp, err := serializeMultiaddrsParams(cctx.Args().Slice()[2:])
+		if err != nil {
+			return err
+		}
+
+		nk, err := getAccount(cctx)
+		if err != nil {
+			return err
+		}
+
+		msiger := NewMsiger()
+
+		if _, err := reviewPendingTxn(cctx, multisigAddr, txid); err != nil {
+			return err
+		}
+
+		proto, err := msiger.MsigApproveTxnHash(multisigAddr, txid, proposer, minerAddr, big.Zero(), sender, uint64(builtin.MethodsMiner.ChangeMultiaddrs), sp)
+		if err != nil {
+			return xerrors.Errorf("approving message: %w", err)
+		}
+
+		msgCid, err := send(cctx, nk, proto)
+		if err != nil {
+			log.Error(err)
+			return err
+		}
+
+		fmt.Fprintln(cctx.App.Writer, "set multiaddrs approve message CID:", msgCid)
+		fmt.Println(fmt.Sprintf("%s%s", config.Conf().Chain.Explorer, msgCid.String()))
+
+		return waitMsg(msgCid.String())
+	},
+}
+
 var msigProposeChangeBeneficiary = &cli.Command{
 	Name:      "propose-change-beneficiary",
 	Usage:     "Propose a beneficiary address change",
@@ -2862,7 +3550,7 @@ var msigProposeChangeBeneficiary = &cli.Command{
 			return err
 		}
 
-		msgCid, err := send(nk, proto)
+		msgCid, err := send(cctx, nk, proto)
 		if err != nil {
 			log.Error(err)
 			return err
@@ -2947,7 +3635,7 @@ var msigConfirmChangeBeneficiary = &cli.Command{
 			return err
 		}
 
-		msgCid, err := send(nk, proto)
+		msgCid, err := send(cctx, nk, proto)
 		if err != nil {
 			log.Error(err)
 			return err
@@ -2995,6 +3683,17 @@ func getInputs(cctx *cli.Context) (address.Address, address.Address, address.Add
 }
 
 func waitProposalMsg(msgCidStr string) error {
+	ret, err := waitProposal(msgCidStr)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("txId: %d ", ret.TxnID)
+	return nil
+}
+
+// waitProposal waits for the propose message and decodes its return
+func waitProposal(msgCidStr string) (*multisig.ProposeReturn, error) {
 	fmt.Println("message waiting for confirmation...")
 	conf := config.Conf()
 
@@ -3003,14 +3702,14 @@ func waitProposalMsg(msgCidStr string) error {
 	var i int = 0
 	for {
 		if i > 60 {
-			return xerrors.New("wait timeout")
+			return nil, xerrors.New("wait timeout")
 		}
 
 		time.Sleep(30 * time.Second)
 		wait, err = client.LotusStateSearchMsg(conf.Chain.RpcAddr, conf.Chain.Token, msgCidStr)
 		if err != nil {
 			log.Error(err)
-			return err
+			return nil, err
 		}
 		if wait == nil {
 			i++
@@ -3020,18 +3719,18 @@ func waitProposalMsg(msgCidStr string) error {
 		break
 	}
 
+	journalConfirmed(msgCidStr, wait)
 	if wait.Receipt.ExitCode != 0 {
-		return fmt.Errorf("propose returned exit %d", wait.Receipt.ExitCode)
+		return nil, fmt.Errorf("propose returned exit %d", wait.Receipt.ExitCode)
 	}
 
 	var ret multisig.ProposeReturn
 	err = ret.UnmarshalCBOR(bytes.NewReader(wait.Receipt.Return))
 	if err != nil {
-		return xerrors.Errorf("decoding proposal return: %w", err)
+		return nil, xerrors.Errorf("decoding proposal return: %w", err)
 	}
 
-	fmt.Printf("txId: %d ", ret.TxnID)
-	return nil
+	return &ret, nil
 }
 
 func waitMsg(msgCidStr string) error {
@@ -3059,6 +3758,7 @@ func waitMsg(msgCidStr string) error {
 		break
 	}
 
+	journalConfirmed(msgCidStr, wait)
 	if wait.Receipt.ExitCode != 0 {
 		return fmt.Errorf("msg returned exit %d", wait.Receipt.ExitCode)
 	}
@@ -3070,28 +3770,80 @@ func waitMsg(msgCidStr string) error {
 
 // ------------------------------------
 
-type msig struct{}
+type msig struct {
+	// nv is the network version the messages are built for, read once
+	nv network.Version
+}
 
 func NewMsiger() *msig {
 	return &msig{}
 }
 
 func (m *msig) messageBuilder(from address.Address) (multisig.MessageBuilder, error) {
-	av, err := actorstypes.VersionForNetwork(network.Version22)
+	if m.nv == 0 {
+		nv, err := networkVersion()
+		if err != nil {
+			return nil, xerrors.Errorf("getting the network version: %w", err)
+		}
+		m.nv = nv
+	}
+
+	av, err := actorstypes.VersionForNetwork(m.nv)
 	if err != nil {
-		return nil, err
+		return nil, xerrors.Errorf("network version %d is not supported by this build, upgrade fil-wallet: %w", m.nv, err)
+	}
+
+	supported := false
+	for _, v := range actors.Versions {
+		if actorstypes.Version(v) == av {
+			supported = true
+		}
+	}
+	if !supported {
+		return nil, xerrors.Errorf("actors version %d of network version %d is not supported by this build, upgrade fil-wallet", av, m.nv)
 	}
 
 	return multisig.Message(av, from), nil
 }
 
-func (m *msig) MsigCreate(req uint64, addrs []address.Address, duration abi.ChainEpoch, val types.BigInt, src address.Address, gp types.BigInt) (*types.Message, error) {
+// networkVersion returns the network version of the node at the head, chain.networkVersion of the
+// config is only used when the node can't be reached
+func networkVersion() (network.Version, error) {
+	conf := config.Conf()
+	nv, err := nodeNetworkVersion()
+	if err != nil {
+		if conf.Chain.NetworkVersion == 0 {
+			return 0, err
+		}
+		log.Warnw("node unreachable, building the multisig messages for the configured network version", "networkVersion", conf.Chain.NetworkVersion, "err", err)
+		return network.Version(conf.Chain.NetworkVersion), nil
+	}
+
+	if conf.Chain.NetworkVersion != 0 && network.Version(conf.Chain.NetworkVersion) != nv {
+		return 0, xerrors.Errorf("chain.networkVersion is %d but the node is at network version %d, fix or clear the config", conf.Chain.NetworkVersion, nv)
+	}
+
+	return nv, nil
+}
+
+func nodeNetworkVersion() (network.Version, error) {
+	conf := config.Conf()
+	api, closer, err := client.NewLotusAPI(conf.Chain.RpcAddr, conf.Chain.Token)
+	if err != nil {
+		return 0, err
+	}
+	defer closer()
+
+	return api.StateNetworkVersion(context.Background(), types.EmptyTSK)
+}
+
+func (m *msig) MsigCreate(req uint64, addrs []address.Address, start, duration abi.ChainEpoch, val types.BigInt, src address.Address, gp types.BigInt) (*types.Message, error) {
 	mb, err := m.messageBuilder(src)
 	if err != nil {
 		return nil, err
 	}
 
-	msg, err := mb.Create(addrs, req, 0, duration, val)
+	msg, err := mb.Create(addrs, req, start, duration, val)
 	if err != nil {
 		return nil, err
 	}
@@ -3250,6 +4002,9 @@ func (m *msig) MsigApproveOrCancelTxnHash(operation api.MsigProposeResponse, msi
 		Method:    abi.MethodNum(method),
 		Params:    params,
 	}
+	if err := checkProposalHash(msig, txID, &p); err != nil {
+		return nil, err
+	}
 
 	mb, err := m.messageBuilder(src)
 	if err != nil {
@@ -3272,6 +4027,46 @@ func (m *msig) MsigApproveOrCancelTxnHash(operation api.MsigProposeResponse, msi
 	return msg, nil
 }
 
+// checkProposalHash fails early when the pending transaction in the state doesn't match the
+// proposal hash data, the actor would refuse the approval or cancel anyway
+func checkProposalHash(maddr address.Address, txID uint64, p *multisig.ProposalHashData) error {
+	conf := config.Conf()
+	api, closer, err := client.NewLotusAPI(conf.Chain.RpcAddr, conf.Chain.Token)
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	tx, err := loadPendingTxn(context.Background(), api, maddr, txID)
+	if err != nil {
+		return err
+	}
+
+	pending := multisig.ProposalHashData{
+		Requester: tx.Approved[0],
+		To:        tx.To,
+		Value:     tx.Value,
+		Method:    tx.Method,
+		Params:    tx.Params,
+	}
+	want, err := pending.Serialize()
+	if err != nil {
+		return err
+	}
+	got, err := p.Serialize()
+	if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(want, got) {
+		return xerrors.Errorf("pending transaction %d is proposed by %s, sends %s to %s calling method %d with params %x, not the transaction expected: proposed by %s, sends %s to %s calling method %d with params %x",
+			txID, pending.Requester, types.FIL(pending.Value), pending.To, pending.Method, pending.Params,
+			p.Requester, types.FIL(p.Value), p.To, p.Method, p.Params)
+	}
+
+	return nil
+}
+
 func serializeAddParams(new address.Address, inc bool) ([]byte, error) {
 	enc, actErr := actors.SerializeParams(&msig2.AddSignerParams{
 		Signer:   new,
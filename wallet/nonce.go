@@ -0,0 +1,90 @@
+package wallet
+
+import (
+	"github.com/filecoin-project/go-address"
+	"github.com/llifezou/fil-wallet/client"
+	"github.com/llifezou/fil-wallet/config"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+	"time"
+)
+
+var nonceOffsetFlag = &cli.Uint64Flag{
+	Name:  "nonce-offset",
+	Usage: "add to the next nonce, to queue several messages behind each other",
+}
+
+// journalPendingWindow is how long a pending message of the journal holds the nonce back, a message that
+// isn't confirmed by then was dropped or the wallet was stopped before waiting for it
+const journalPendingWindow = time.Hour
+
+// pushedNonces is the nonce after the last message each address pushed in this session
+var pushedNonces = make(map[address.Address]uint64)
+
+// messageNonce returns the --nonce of the command when it is set, even to 0,
+// otherwise the next nonce of the address plus --nonce-offset
+func messageNonce(cctx *cli.Context, from address.Address) (uint64, error) {
+	if cctx.IsSet("nonce") {
+		if cctx.IsSet("nonce-offset") {
+			return 0, xerrors.New("can only specify one of 'nonce' and 'nonce-offset'")
+		}
+		return cctx.Uint64("nonce"), nil
+	}
+
+	next, err := nextNonce(from)
+	if err != nil {
+		return 0, err
+	}
+
+	return next + cctx.Uint64("nonce-offset"), nil
+}
+
+// nextNonce returns the highest of the mpool nonce, the nonce after the messages pushed in this session
+// and the nonce after the recent pending messages in the journal, the node may not have seen our latest messages yet
+func nextNonce(from address.Address) (uint64, error) {
+	conf := config.Conf()
+	mpoolNonce, err := client.LotusMpoolGetNonce(conf.Chain.RpcAddr, conf.Chain.Token, from.String())
+	if err != nil {
+		return 0, err
+	}
+	next := uint64(mpoolNonce)
+
+	if pushed, ok := pushedNonces[from]; ok && pushed > next {
+		next = pushed
+	}
+
+	if j := openJournal(); j != nil {
+		var chainNonce uint64
+		_, _, nonce, _, err := client.LotusStateGetActor(conf.Chain.RpcAddr, conf.Chain.Token, from.String())
+		if err != nil {
+			// only a new account has no actor yet and an on-chain nonce of 0, other errors must not look like one
+			notFound, lerr := actorNotFound(from)
+			if lerr != nil {
+				return 0, xerrors.Errorf("looking up the sender %s: %w", from, lerr)
+			}
+			if !notFound {
+				return 0, xerrors.Errorf("looking up the sender %s: %w", from, err)
+			}
+		} else {
+			chainNonce = uint64(nonce)
+		}
+
+		pending, ok, err := j.NextNonce(from, chainNonce, time.Now().Add(-journalPendingWindow))
+		if err != nil {
+			return 0, err
+		}
+		if ok && pending > next {
+			log.Infow("using the nonce after pending messages in the journal", "from", from, "mpool", uint64(mpoolNonce), "nonce", pending)
+			next = pending
+		}
+	}
+
+	return next, nil
+}
+
+// notePushed records a message pushed in this session
+func notePushed(from address.Address, nonce uint64) {
+	if nonce+1 > pushedNonces[from] {
+		pushedNonces[from] = nonce + 1
+	}
+}
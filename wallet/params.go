@@ -19,7 +19,6 @@ type SendParams struct {
 	GasFeeCap  *abi.TokenAmount
 	GasLimit   *int64
 
-	Nonce  *uint64
 	Method abi.MethodNum
 	Params []byte
 }
@@ -93,10 +92,5 @@ func getParams(cctx *cli.Context) (*SendParams, error) {
 		params.Params = decparams
 	}
 
-	if cctx.IsSet("nonce") {
-		n := cctx.Uint64("nonce")
-		params.Nonce = &n
-	}
-
 	return &params, nil
 }
@@ -0,0 +1,211 @@
+package wallet
+
+import (
+	"fmt"
+	"github.com/fatih/color"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/ipfs/go-cid"
+	"github.com/llifezou/fil-wallet/client"
+	"github.com/llifezou/fil-wallet/config"
+	"github.com/llifezou/fil-wallet/policy"
+	"github.com/llifezou/fil-wallet/util"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+	"path/filepath"
+	"time"
+)
+
+var overridePolicyFlag = &cli.BoolFlag{
+	Name:  "override-policy",
+	Usage: "send the message even if it violates the spending policy, requires the policy override password",
+	Value: false,
+}
+
+var policyCmd = &cli.Command{
+	Name:  "policy",
+	Usage: "Manage the spending policy",
+	Subcommands: []*cli.Command{
+		policyHashPasswordCmd,
+		policyOutflowCmd,
+	},
+}
+
+var policyHashPasswordCmd = &cli.Command{
+	Name:  "hash-password",
+	Usage: "Hash an override password for the overridePassword field of the policy file",
+	Action: func(cctx *cli.Context) error {
+		password, err := util.GetPassword(true)
+		if err != nil {
+			return err
+		}
+
+		h, err := policy.HashPassword(password)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(h)
+		return nil
+	},
+}
+
+var policyOutflowCmd = &cli.Command{
+	Name:      "outflow",
+	Usage:     "Show the outflow of an address in the last 24 hours and its policy limits",
+	ArgsUsage: "<address>",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "conf-path",
+			Usage: "config.yaml path",
+			Value: "",
+		},
+	},
+	Before: func(c *cli.Context) error {
+		config.InitConfig(c.String("conf-path"))
+		return nil
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return fmt.Errorf("must pass address")
+		}
+
+		addr, err := address.NewFromString(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		p, ledger, err := loadPolicy()
+		if err != nil {
+			return err
+		}
+		if p == nil {
+			return xerrors.New("no policy configured, set policy.path in config.yaml")
+		}
+
+		spent, err := ledger.Outflow(addr, time.Now().Add(-24*time.Hour))
+		if err != nil {
+			return err
+		}
+
+		r := p.Rule(addr)
+		fmt.Printf("Outflow (24h): %s\n", types.FIL(spent))
+		fmt.Printf("Max value: %s\n", orUnlimited(r.MaxValue))
+		fmt.Printf("Daily outflow: %s\n", orUnlimited(r.DailyOutflow))
+		fmt.Printf("Max fee cap: %s\n", orUnlimited(r.MaxFeeCap))
+		fmt.Printf("Allow: %v\n", r.Allow)
+		fmt.Printf("Deny: %v\n", r.Deny)
+		return nil
+	},
+}
+
+// loadPolicy returns a nil policy when no policy file is configured
+func loadPolicy() (*policy.Policy, *policy.Ledger, error) {
+	conf := config.Conf()
+	if conf.Policy.Path == "" {
+		return nil, nil, nil
+	}
+
+	p, err := policy.Load(conf.Policy.Path)
+	if err != nil {
+		return nil, nil, err
+	}
+	p.SetResolver(func(addr address.Address) (address.Address, error) {
+		id, err := client.LotusStateLookupID(conf.Chain.RpcAddr, conf.Chain.Token, addr.String())
+		if err != nil {
+			return address.Undef, err
+		}
+		return address.NewFromString(id)
+	})
+
+	ledgerPath := conf.Policy.Ledger
+	if ledgerPath == "" {
+		ledgerPath = filepath.Join(filepath.Dir(conf.Policy.Path), "ledger.jsonl")
+	}
+
+	return p, policy.NewLedger(ledgerPath), nil
+}
+
+// checkPolicy is called before a message is signed, a violation blocks the message unless
+// --override-policy is set and the override password is confirmed
+func checkPolicy(cctx *cli.Context, msg *types.Message) error {
+	p, ledger, err := loadPolicy()
+	if err != nil {
+		return err
+	}
+	if p == nil {
+		return nil
+	}
+
+	spent, err := ledger.Outflow(msg.From, time.Now().Add(-24*time.Hour))
+	if err != nil {
+		return err
+	}
+
+	violation := p.Check(msg, spent)
+	if violation == nil {
+		violation = checkSignRules(p, msg)
+	}
+	if violation == nil {
+		return nil
+	}
+
+	if !cctx.Bool("override-policy") {
+		return xerrors.Errorf("%w, pass --override-policy to override", violation)
+	}
+
+	color.Red(violation.Error())
+	color.Red("enter the policy override password to send anyway")
+
+	password, err := util.GetPassword(false)
+	if err != nil {
+		return err
+	}
+
+	if err := p.VerifyOverride(password); err != nil {
+		return err
+	}
+
+	log.Warnw("spending policy overridden", "from", msg.From, "to", msg.To, "value", types.FIL(msg.Value), "violation", violation)
+	return nil
+}
+
+// checkSignRules evaluates the sign rules of the sender, the method name is only resolved when it has some
+func checkSignRules(p *policy.Policy, msg *types.Message) error {
+	if len(p.Rule(msg.From).Sign) == 0 {
+		return nil
+	}
+
+	method, err := messageMethodName(msg)
+	if err != nil {
+		return xerrors.Errorf("resolving the method for the sign rules: %w", err)
+	}
+
+	return p.CheckSign(msg, method)
+}
+
+// recordOutflow writes a pushed message to the policy ledger, the message is already pushed so errors are only logged
+func recordOutflow(msg *types.Message, msgCid cid.Cid) {
+	_, ledger, err := loadPolicy()
+	if err != nil || ledger == nil {
+		return
+	}
+
+	err = ledger.Record(policy.Entry{
+		Time:  time.Now(),
+		From:  msg.From,
+		To:    msg.To,
+		Value: msg.Value,
+		Cid:   msgCid.String(),
+	})
+	if err != nil {
+		log.Errorw("failed to record outflow", "cid", msgCid, "err", err)
+	}
+}
+
+func orUnlimited(s string) string {
+	if s == "" {
+		return "unlimited"
+	}
+	return s
+}
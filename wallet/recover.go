@@ -0,0 +1,134 @@
+package wallet
+
+import (
+	"fmt"
+	"github.com/fatih/color"
+	"github.com/filecoin-project/go-address"
+	"github.com/llifezou/fil-wallet/config"
+	"github.com/llifezou/fil-wallet/mnemonic"
+	"github.com/llifezou/fil-wallet/util"
+	"github.com/llifezou/hdwallet"
+	"github.com/tyler-smith/go-bip39"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+	"strings"
+)
+
+var walletRecover = &cli.Command{
+	Name:      "recover",
+	Usage:     "Recover a mistyped mnemonic by trying single word substitutions or a missing final word against a known address",
+	ArgsUsage: "<address>",
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:  "index-end",
+			Usage: "look for the address at index 0 - index-end",
+			Value: 0,
+		},
+		&cli.StringFlag{
+			Name:  "conf-path",
+			Usage: "config.yaml path",
+			Value: "",
+		},
+	},
+	Before: func(c *cli.Context) error {
+		config.InitConfig(c.String("conf-path"))
+		return nil
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return fmt.Errorf("must pass the address the mnemonic derives")
+		}
+
+		target, err := address.NewFromString(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		var t string
+		switch target.Protocol() {
+		case address.SECP256K1:
+			t = "secp256k1"
+		case address.BLS:
+			t = "bls"
+		default:
+			return xerrors.Errorf("%s is not a key address, must be f1 / f3", target)
+		}
+
+		conf := config.Conf()
+		if conf.Account.Mnemonic == "" {
+			return xerrors.New("mnemonic is null")
+		}
+		m := mnemonic.Normalize(conf.Account.Mnemonic)
+
+		candidates, err := mnemonic.Candidates(m)
+		if err != nil {
+			return err
+		}
+		// a valid mnemonic may only miss the right password or index, or be written with
+		// irregular whitespace, which derives from the mnemonic as written
+		if mnemonic.Validate(m) == nil {
+			candidates = append([]string{m}, candidates...)
+			if raw := conf.Account.Mnemonic; raw != m {
+				if _, err := bip39.MnemonicToByteArray(raw); err == nil {
+					candidates = append([]string{raw}, candidates...)
+				}
+			}
+		}
+
+		var password string
+		if conf.Account.Password {
+			password, err = util.GetPassword(false)
+			if err != nil {
+				return err
+			}
+		}
+
+		log.Infow("trying candidates", "count", len(candidates), "address", target, "index-end", cctx.Int("index-end"))
+		for _, c := range candidates {
+			seed, err := hdwallet.GenerateSeedFromMnemonic(c, password)
+			if err != nil {
+				return err
+			}
+
+			for i := 0; i <= cctx.Int("index-end"); i++ {
+				nk, err := newHDKey(seed, t, i)
+				if err != nil {
+					return err
+				}
+				if nk.Address != target {
+					continue
+				}
+
+				color.Red("一定保存好助记词，丢失助记词将导致所有财产损失！")
+				color.Red("Be sure to save mnemonic. Losing mnemonic will cause all property damage!")
+
+				fmt.Printf("\n")
+				fmt.Println(c)
+				fmt.Printf("index %d derives %s\n", i, target)
+				if diff := wordDiff(m, c); diff != "" {
+					fmt.Printf("changed: %s\n", diff)
+				}
+				return nil
+			}
+		}
+
+		return xerrors.Errorf("none of the %d candidates derives %s at index 0 - %d, check the password or try a larger --index-end", len(candidates), target, cctx.Int("index-end"))
+	},
+}
+
+// wordDiff describes the words of the candidate that differ from the mnemonic
+func wordDiff(m, c string) string {
+	mw, cw := strings.Fields(m), strings.Fields(c)
+
+	var diff []string
+	for i, w := range cw {
+		switch {
+		case i >= len(mw):
+			diff = append(diff, fmt.Sprintf("word %d added %q", i+1, w))
+		case mw[i] != w:
+			diff = append(diff, fmt.Sprintf("word %d %q -> %q", i+1, mw[i], w))
+		}
+	}
+
+	return strings.Join(diff, ", ")
+}
@@ -6,12 +6,27 @@ import (
 	"github.com/ipfs/go-cid"
 	"github.com/llifezou/fil-wallet/client"
 	"github.com/llifezou/fil-wallet/config"
+	"github.com/urfave/cli/v2"
 	"golang.org/x/xerrors"
 )
 
-func send(account *key.Key, message *types.Message) (cid.Cid, error) {
-	var err error
-	message, err = estimateMessageGasAndNonce(message)
+func send(cctx *cli.Context, account *key.Key, message *types.Message) (cid.Cid, error) {
+	message, outflow, err := wrapViaMsig(cctx, account, message)
+	if err != nil {
+		return cid.Undef, err
+	}
+
+	maxFee, err := messageMaxFee(cctx)
+	if err != nil {
+		return cid.Undef, err
+	}
+
+	message, err = estimateMessageGas(message, maxFee)
+	if err != nil {
+		return cid.Undef, err
+	}
+
+	message.Nonce, err = messageNonce(cctx, message.From)
 	if err != nil {
 		return cid.Undef, err
 	}
@@ -22,6 +37,16 @@ func send(account *key.Key, message *types.Message) (cid.Cid, error) {
 
 	conf := config.Conf()
 
+	err = checkPolicy(cctx, message)
+	if err != nil {
+		return cid.Undef, err
+	}
+
+	err = confirmMessage(cctx, message)
+	if err != nil {
+		return cid.Undef, err
+	}
+
 	signedMessage, err := signMessage(account, message)
 	if err != nil {
 		return cid.Undef, err
@@ -32,5 +57,9 @@ func send(account *key.Key, message *types.Message) (cid.Cid, error) {
 		return cid.Undef, err
 	}
 
+	notePushed(message.From, message.Nonce)
+	recordOutflow(outflow, msgCid)
+	journalSent(cctx, message, msgCid)
+
 	return msgCid, nil
 }
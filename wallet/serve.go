@@ -0,0 +1,234 @@
+package wallet
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/wallet/key"
+	"github.com/ipfs/go-cid"
+	"github.com/llifezou/fil-wallet/config"
+	"github.com/llifezou/fil-wallet/signer"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+var ServeCmd = &cli.Command{
+	Name:  "serve",
+	Usage: "Serve the keys as a remote signer compatible with the lotus wallet api (Wallet.RemoteBackend)",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "listen",
+			Usage: "address to listen on, the api is served at /rpc/v0",
+			Value: "127.0.0.1:1777",
+		},
+		&cli.StringFlag{
+			Name:  "type",
+			Usage: "wallet type, ps: secp256k1, bls",
+			Value: "secp256k1",
+		},
+		&cli.IntFlag{
+			Name:  "index-end",
+			Usage: "serve the keys of index 0 - index-end",
+			Value: 0,
+		},
+		&cli.StringFlag{
+			Name:  "conf-path",
+			Usage: "config.yaml path",
+			Value: "",
+		},
+	},
+	Subcommands: []*cli.Command{
+		serveTokenCmd,
+	},
+	Before: func(c *cli.Context) error {
+		config.InitConfig(c.String("conf-path"))
+		return nil
+	},
+	Action: func(cctx *cli.Context) error {
+		secret, err := signerSecret()
+		if err != nil {
+			return err
+		}
+
+		var keys []*key.Key
+		var newKey signer.NewKeyFunc
+		if config.Conf().Account.Key != "" {
+			nk, err := getAccount(cctx)
+			if err != nil {
+				return err
+			}
+			keys = append(keys, nk)
+		} else {
+			seed, err := accountSeed()
+			if err != nil {
+				return err
+			}
+
+			for i := 0; i <= cctx.Int("index-end"); i++ {
+				nk, err := deriveKey(seed, cctx.String("type"), i)
+				if err != nil {
+					return err
+				}
+				keys = append(keys, nk)
+			}
+
+			// WalletNew derives the key after the last one served of the type
+			var lk sync.Mutex
+			next := map[types.KeyType]int{types.KeyType(cctx.String("type")): cctx.Int("index-end") + 1}
+			newKey = func(typ types.KeyType) (*key.Key, error) {
+				lk.Lock()
+				defer lk.Unlock()
+
+				nk, err := deriveKey(seed, string(typ), next[typ])
+				if err != nil {
+					return nil, err
+				}
+				next[typ]++
+				return nk, nil
+			}
+		}
+
+		s, err := signer.New(secret, signerCheck, newKey)
+		if err != nil {
+			return err
+		}
+		for _, k := range keys {
+			s.AddKey(k)
+			log.Infow("serving key", "address", k.Address)
+		}
+
+		mux := http.NewServeMux()
+		mux.Handle("/rpc/v0", s.Handler())
+		srv := &http.Server{
+			Addr:              cctx.String("listen"),
+			Handler:           mux,
+			ReadHeaderTimeout: 30 * time.Second,
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		go func() {
+			<-ctx.Done()
+			log.Info("shutting down the signer")
+			_ = srv.Shutdown(context.Background())
+		}()
+
+		log.Infow("signer listening", "addr", srv.Addr)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			return err
+		}
+		return nil
+	},
+}
+
+var serveTokenCmd = &cli.Command{
+	Name:  "token",
+	Usage: "Create a token for the signer",
+	Flags: []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:  "allow",
+			Usage: "permissions of the token, ps: read, sign, admin",
+			Value: cli.NewStringSlice("read", "sign"),
+		},
+		&cli.StringSliceFlag{
+			Name:  "address",
+			Usage: "addresses the token may use, all addresses when not set",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		secret, err := signerSecret()
+		if err != nil {
+			return err
+		}
+
+		c := signer.Claims{Allow: cctx.StringSlice("allow")}
+		for _, s := range cctx.StringSlice("address") {
+			addr, err := address.NewFromString(s)
+			if err != nil {
+				return err
+			}
+			c.Addresses = append(c.Addresses, addr)
+		}
+
+		token, err := signer.NewToken(secret, c)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(token)
+		return nil
+	},
+}
+
+func signerSecret() ([]byte, error) {
+	s := config.Conf().Signer.Secret
+	if s == "" {
+		return nil, xerrors.New("signer.secret is not set in config.yaml, generate one with: openssl rand -hex 32")
+	}
+
+	secret, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, xerrors.Errorf("decoding signer.secret: %w", err)
+	}
+	return secret, nil
+}
+
+// signerCheck checks the signing requests against the spending policy before the signer signs them,
+// the outflow is recorded when signing since the signer never sees the message being pushed
+func signerCheck(_ context.Context, addr address.Address, toSign []byte, meta api.MsgMeta) error {
+	p, ledger, err := loadPolicy()
+	if err != nil {
+		return err
+	}
+	if p == nil {
+		return nil
+	}
+
+	if meta.Type != api.MTChainMsg {
+		// the signature of a chain message is over its cid, a cid signed as raw bytes would be a signed
+		// message that never went through the policy
+		if c, err := cid.Cast(toSign); err == nil {
+			return xerrors.Errorf("policy violation: refusing to sign cid %s as %s, messages must be sent as chain messages", c, meta.Type)
+		}
+		if err := p.CheckSignType(addr, string(meta.Type)); err != nil {
+			return err
+		}
+		if meta.Type == api.MTBlock {
+			if _, err := types.DecodeBlock(toSign); err != nil {
+				return xerrors.Errorf("signing request is not a block: %w", err)
+			}
+		}
+		return nil
+	}
+
+	msg, err := signer.ChainMessage(toSign, meta)
+	if err != nil {
+		return err
+	}
+	msgCid := msg.Cid()
+	// the node may send from the id address, the policy rules use the key address
+	msg.From = addr
+
+	spent, err := ledger.Outflow(msg.From, time.Now().Add(-24*time.Hour))
+	if err != nil {
+		return err
+	}
+	if err := p.Check(msg, spent); err != nil {
+		return err
+	}
+	if err := checkSignRules(p, msg); err != nil {
+		return err
+	}
+
+	recordOutflow(msg, msgCid)
+	return nil
+}
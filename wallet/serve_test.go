@@ -0,0 +1,56 @@
+package wallet
+
+import (
+	"context"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/llifezou/fil-wallet/config"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSignerCheckRawRequests(t *testing.T) {
+	from, _ := address.NewFromString("f1s6p5rqjg7msu6xoseyznniarazsyh5ukbned4yi")
+	to, _ := address.NewFromString("f1b2j6uc4mxxd5yqw2d7jgae4wsf3knvlwtuhinpy")
+
+	dir := t.TempDir()
+	policyPath := filepath.Join(dir, "policy.yaml")
+	err := os.WriteFile(policyPath, []byte(`
+default:
+  maxValue: 1
+addresses:
+  `+from.String()+`:
+    signTypes: [unknown]
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	confPath := filepath.Join(dir, "config.yaml")
+	err = os.WriteFile(confPath, []byte(`
+chain:
+  rpcAddr: http://127.0.0.1:1
+policy:
+  path: `+policyPath+`
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.InitConfig(confPath)
+
+	// a message over the max value, signed through its cid as a raw request
+	msg := &types.Message{From: from, To: to, Value: types.FromFil(100)}
+	if err := signerCheck(context.Background(), from, msg.Cid().Bytes(), api.MsgMeta{Type: api.MTUnknown}); err == nil {
+		t.Fatal("expected the cid of a message to be refused as a raw request")
+	}
+
+	if err := signerCheck(context.Background(), from, []byte("statement"), api.MsgMeta{Type: api.MTUnknown}); err != nil {
+		t.Fatalf("expected raw requests allowed by signTypes to be signed: %s", err)
+	}
+
+	if err := signerCheck(context.Background(), to, []byte("statement"), api.MsgMeta{Type: api.MTUnknown}); err == nil {
+		t.Fatal("expected raw requests to be refused by default")
+	}
+}
@@ -0,0 +1,184 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/builtin"
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/actors/adt"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/multisig"
+	"github.com/filecoin-project/lotus/chain/types"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/llifezou/fil-wallet/client"
+	"github.com/llifezou/fil-wallet/config"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+	"text/tabwriter"
+	"time"
+)
+
+var msigVestingCmd = &cli.Command{
+	Name:      "vesting",
+	Usage:     "Print the unlock curve of a multisig and the amount spendable now, and propose a transfer of it",
+	ArgsUsage: "<multisigAddress>",
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:  "points",
+			Usage: "number of steps of the unlock curve",
+			Value: 10,
+		},
+		&cli.StringFlag{
+			Name:  "propose-to",
+			Usage: "propose a transfer of the whole spendable amount to this address",
+		},
+		&cli.StringFlag{
+			Name:  "from",
+			Usage: "account to send the propose message from, with --propose-to",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 1 {
+			return fmt.Errorf("must specify address of multisig")
+		}
+		if cctx.Int("points") < 1 {
+			return fmt.Errorf("--points must be at least 1")
+		}
+
+		maddr, err := address.NewFromString(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		conf := config.Conf()
+		api, closer, err := client.NewLotusAPI(conf.Chain.RpcAddr, conf.Chain.Token)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := context.Background()
+		store := adt.WrapStore(ctx, cbor.NewCborStore(blockstore.NewAPIBlockstore(api)))
+
+		head, err := api.ChainHead(ctx)
+		if err != nil {
+			return err
+		}
+
+		act, err := api.StateGetActor(ctx, maddr, head.Key())
+		if err != nil {
+			return err
+		}
+
+		mstate, err := multisig.Load(store, act)
+		if err != nil {
+			return err
+		}
+
+		ib, err := mstate.InitialBalance()
+		if err != nil {
+			return err
+		}
+		se, err := mstate.StartEpoch()
+		if err != nil {
+			return err
+		}
+		ud, err := mstate.UnlockDuration()
+		if err != nil {
+			return err
+		}
+		locked, err := mstate.LockedBalance(head.Height())
+		if err != nil {
+			return err
+		}
+
+		// epochs are converted to times from the head, every epoch is 30 seconds
+		epochTime := func(e abi.ChainEpoch) string {
+			t := int64(head.MinTimestamp()) + int64(e-head.Height())*int64(builtin.EpochDurationSeconds)
+			return time.Unix(t, 0).Format("2006-01-02 15:04")
+		}
+
+		spendable := big.Sub(act.Balance, locked)
+		if spendable.LessThan(big.Zero()) {
+			spendable = big.Zero()
+		}
+
+		fmt.Printf("Balance: %s\n", types.FIL(act.Balance))
+		fmt.Printf("InitialBalance: %s\n", types.FIL(ib))
+		fmt.Printf("StartEpoch: %d (%s)\n", se, epochTime(se))
+		fmt.Printf("UnlockDuration: %d\n", ud)
+		fmt.Printf("UnlockEpoch: %d (%s)\n", se+ud, epochTime(se+ud))
+		fmt.Printf("Locked: %s at epoch %d\n", types.FIL(locked), head.Height())
+		fmt.Println()
+
+		if ud > 0 && !ib.IsZero() {
+			points := abi.ChainEpoch(cctx.Int("points"))
+			w := tabwriter.NewWriter(cctx.App.Writer, 8, 4, 2, ' ', 0)
+			fmt.Fprintf(w, "Epoch\tTime\tLocked\tUnlocked\tVested\n")
+			for i := abi.ChainEpoch(0); i <= points; i++ {
+				e := se + ud*i/points
+				l, err := mstate.LockedBalance(e)
+				if err != nil {
+					return err
+				}
+
+				unlocked := big.Sub(ib, l)
+				vested := big.Div(big.Mul(unlocked, big.NewInt(100)), ib)
+				now := ""
+				if e <= head.Height() {
+					now = " (passed)"
+				}
+				fmt.Fprintf(w, "%d%s\t%s\t%s\t%s\t%s%%\n", e, now, epochTime(e), types.FIL(l), types.FIL(unlocked), vested)
+			}
+			if err := w.Flush(); err != nil {
+				return xerrors.Errorf("flushing output: %+v", err)
+			}
+			fmt.Println()
+		} else {
+			fmt.Println("no vesting schedule, the whole balance is spendable")
+		}
+
+		// pending transactions don't reserve funds, anything above the locked balance can be sent
+		fmt.Printf("Spendable now: %s\n", types.FIL(spendable))
+
+		if !cctx.IsSet("propose-to") {
+			return nil
+		}
+
+		if spendable.IsZero() {
+			return xerrors.New("nothing is spendable yet")
+		}
+
+		to, err := address.NewFromString(cctx.String("propose-to"))
+		if err != nil {
+			return err
+		}
+
+		from, err := address.NewFromString(cctx.String("from"))
+		if err != nil {
+			return xerrors.Errorf("--from: %w", err)
+		}
+
+		nk, err := getAccount(cctx)
+		if err != nil {
+			return err
+		}
+
+		proto, err := NewMsiger().MsigPropose(maddr, to, types.BigInt(spendable), from, uint64(builtin.MethodSend), nil)
+		if err != nil {
+			return err
+		}
+
+		msgCid, err := send(cctx, nk, proto)
+		if err != nil {
+			log.Error(err)
+			return err
+		}
+
+		fmt.Println("sent proposal in message: ", msgCid)
+		fmt.Println(fmt.Sprintf("%s%s", config.Conf().Chain.Explorer, msgCid.String()))
+
+		return waitProposalMsg(msgCid.String())
+	},
+}
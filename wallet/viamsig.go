@@ -0,0 +1,96 @@
+package wallet
+
+import (
+	"fmt"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/wallet/key"
+	"github.com/llifezou/fil-wallet/client"
+	"github.com/llifezou/fil-wallet/config"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+)
+
+var viaMsigFlag = &cli.StringFlag{
+	Name:  "via-msig",
+	Usage: "propose the message of the command through this multisig instead of sending it, the wallet key proposes",
+}
+
+// wrapViaMsig turns the message into a proposal of the --via-msig multisig, the multisig becomes the
+// sender of the message and the wallet key sends the proposal. The outflow is the message the spending
+// policy of the wallet key is checked and recorded on, the proposed message when it's wrapped.
+func wrapViaMsig(cctx *cli.Context, account *key.Key, message *types.Message) (*types.Message, *types.Message, error) {
+	if !cctx.IsSet("via-msig") {
+		return message, message, nil
+	}
+
+	msig, err := address.NewFromString(cctx.String("via-msig"))
+	if err != nil {
+		return nil, nil, xerrors.Errorf("parsing --via-msig: %w", err)
+	}
+
+	isMsig, err := isMultisigActor(msig.String())
+	if err != nil {
+		return nil, nil, xerrors.Errorf("failed to look up multisig %s: %w", msig, err)
+	}
+	if !isMsig {
+		return nil, nil, xerrors.Errorf("actor %s is not a multisig actor", msig)
+	}
+
+	if message.From != msig {
+		log.Infow("the message is proposed through the multisig, its sender is replaced", "from", message.From, "multisig", msig)
+	}
+
+	// the policy of the wallet key applies to what it proposes, not only to the proposal itself
+	inner := *message
+	inner.From = account.Address
+	if err := checkPolicy(cctx, &inner); err != nil {
+		return nil, nil, err
+	}
+
+	proto, err := NewMsiger().MsigPropose(msig, message.To, message.Value, account.Address, uint64(message.Method), message.Params)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// the gas flags of the command apply to the proposal, the rest is estimated for the proposal
+	if cctx.IsSet("gas-limit") {
+		proto.GasLimit = message.GasLimit
+	}
+	if cctx.IsSet("gas-feecap") {
+		proto.GasFeeCap = message.GasFeeCap
+	}
+	if cctx.IsSet("gas-premium") {
+		proto.GasPremium = message.GasPremium
+	}
+
+	fmt.Printf("proposing the message to %s through multisig %s, the other signers approve it with `msig approve-any %s <txId>`\n", message.To, msig, msig)
+	return proto, &inner, nil
+}
+
+// roleSender resolves the sender of a message signed by a miner role: the --via-msig multisig when the
+// role is that multisig, the wallet key then proposes the message, the account key of the role otherwise
+func roleSender(cctx *cli.Context, role address.Address) (address.Address, error) {
+	if cctx.IsSet("via-msig") {
+		msig, err := address.NewFromString(cctx.String("via-msig"))
+		if err != nil {
+			return address.Undef, xerrors.Errorf("parsing --via-msig: %w", err)
+		}
+
+		conf := config.Conf()
+		msigID, err := client.LotusStateLookupID(conf.Chain.RpcAddr, conf.Chain.Token, msig.String())
+		if err != nil {
+			return address.Undef, xerrors.Errorf("failed to look up multisig %s: %w", msig, err)
+		}
+		roleID, err := client.LotusStateLookupID(conf.Chain.RpcAddr, conf.Chain.Token, role.String())
+		if err != nil {
+			return address.Undef, xerrors.Errorf("failed to look up %s: %w", role, err)
+		}
+
+		if msigID == roleID {
+			return msig, nil
+		}
+	}
+
+	return minerAccountKey(role.String())
+}
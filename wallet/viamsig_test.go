@@ -0,0 +1,151 @@
+package wallet
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/builtin"
+	"github.com/filecoin-project/lotus/chain/actors"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/wallet/key"
+	builtin2 "github.com/filecoin-project/specs-actors/v2/actors/builtin"
+	msig2 "github.com/filecoin-project/specs-actors/v2/actors/builtin/multisig"
+	miner5 "github.com/filecoin-project/specs-actors/v5/actors/builtin/miner"
+	"github.com/llifezou/fil-wallet/config"
+	"github.com/urfave/cli/v2"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeNode answers the json rpc calls of the via-msig path, the multisig is the owner f01000
+func fakeNode(t *testing.T, msig address.Address) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     interface{}       `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decoding request: %s", err)
+			return
+		}
+
+		var result interface{}
+		switch req.Method {
+		case "Filecoin.StateLookupID":
+			var addr string
+			_ = json.Unmarshal(req.Params[0], &addr)
+			if addr == msig.String() {
+				addr = "f01000"
+			}
+			result = addr
+		case "Filecoin.StateGetActor":
+			code := map[string]string{"/": builtin2.MultisigActorCodeID.String()}
+			result = map[string]interface{}{"Code": code, "Head": code, "Nonce": 0, "Balance": "0"}
+		case "Filecoin.StateNetworkVersion":
+			result = 21
+		default:
+			t.Errorf("unexpected call %s", req.Method)
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+	}))
+}
+
+func TestWrapViaMsig(t *testing.T) {
+	msig, _ := address.NewActorAddress([]byte("msig"))
+	owner, _ := address.NewIDAddress(1000)
+	maddr, _ := address.NewIDAddress(1002)
+	keyAddr, _ := address.NewFromString("f1s6p5rqjg7msu6xoseyznniarazsyh5ukbned4yi")
+	to, _ := address.NewFromString("f1b2j6uc4mxxd5yqw2d7jgae4wsf3knvlwtuhinpy")
+	account := &key.Key{Address: keyAddr}
+
+	node := fakeNode(t, msig)
+	defer node.Close()
+
+	dir := t.TempDir()
+	policyPath := filepath.Join(dir, "policy.yaml")
+	err := os.WriteFile(policyPath, []byte(`
+default:
+  dailyOutflow: 10
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	confPath := filepath.Join(dir, "viamsig.yaml")
+	err = os.WriteFile(confPath, []byte(`
+chain:
+  rpcAddr: `+node.URL+`
+policy:
+  path: `+policyPath+`
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.InitConfig(confPath)
+
+	set := flag.NewFlagSet("test", flag.ContinueOnError)
+	set.String("via-msig", "", "")
+	if err := set.Set("via-msig", msig.String()); err != nil {
+		t.Fatal(err)
+	}
+	cctx := cli.NewContext(cli.NewApp(), set, nil)
+
+	// an owner-signed withdraw of a miner owned by the multisig
+	from, err := roleSender(cctx, owner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from != msig {
+		t.Fatalf("expected the multisig owner to send the withdraw, got %s", from)
+	}
+
+	params, err := actors.SerializeParams(&miner5.WithdrawBalanceParams{AmountRequested: types.FromFil(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	proto, outflow, err := wrapViaMsig(cctx, account, &types.Message{
+		From:   from,
+		To:     maddr,
+		Value:  big.Zero(),
+		Method: builtin.MethodsMiner.WithdrawBalance,
+		Params: params,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proto.From != keyAddr || proto.To != msig || proto.Method != builtin.MethodsMultisig.Propose {
+		t.Fatalf("expected a proposal of the wallet key to the multisig, got %+v", proto)
+	}
+	var p msig2.ProposeParams
+	if err := p.UnmarshalCBOR(bytes.NewReader(proto.Params)); err != nil {
+		t.Fatal(err)
+	}
+	if p.To != maddr || p.Method != builtin.MethodsMiner.WithdrawBalance || !bytes.Equal(p.Params, params) {
+		t.Fatalf("unexpected proposed message: %+v", p)
+	}
+	if outflow.From != keyAddr || outflow.To != maddr {
+		t.Fatalf("unexpected outflow: %+v", outflow)
+	}
+
+	// the proposed value counts against the daily outflow of the wallet key
+	send := &types.Message{From: msig, To: to, Value: types.FromFil(6), Method: builtin.MethodSend}
+	proto, outflow, err = wrapViaMsig(cctx, account, send)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !outflow.Value.Equals(types.FromFil(6)) || outflow.From != keyAddr {
+		t.Fatalf("expected the proposed value to be the outflow, got %+v", outflow)
+	}
+	recordOutflow(outflow, proto.Cid())
+
+	if _, _, err := wrapViaMsig(cctx, account, send); err == nil {
+		t.Fatal("expected the second proposal to exceed the daily outflow")
+	}
+}
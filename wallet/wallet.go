@@ -1,6 +1,7 @@
 package wallet
 
 import (
+	"context"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
@@ -14,16 +15,25 @@ import (
 	"github.com/llifezou/fil-sdk/sigs"
 	_ "github.com/llifezou/fil-sdk/sigs/bls"
 	_ "github.com/llifezou/fil-sdk/sigs/secp"
+	"github.com/llifezou/fil-wallet/client"
 	"github.com/llifezou/fil-wallet/config"
+	"github.com/llifezou/fil-wallet/envelope"
+	"github.com/llifezou/fil-wallet/util"
+	"github.com/llifezou/fil-wallet/verify"
 	"github.com/llifezou/hdwallet"
 	"github.com/urfave/cli/v2"
 	"golang.org/x/xerrors"
+	"os"
+	"unicode/utf8"
 )
 
 var log = logging.Logger("wallet")
 var Cmd = &cli.Command{
 	Name:  "wallet",
 	Usage: "fil wallet",
+	Flags: []cli.Flag{
+		viaMsigFlag,
+	},
 	Subcommands: []*cli.Command{
 		mnemonicNew,
 		walletNew,
@@ -35,6 +45,11 @@ var Cmd = &cli.Command{
 		walletSendCmd,
 		minerCmd,
 		multisigCmd,
+		policyCmd,
+		walletHistory,
+		backupCmd,
+		walletRecover,
+		blsCmd,
 		// todo call fvm
 	},
 }
@@ -187,6 +202,18 @@ var walletList = &cli.Command{
 	},
 }
 
+var signModeFlag = &cli.StringFlag{
+	Name:  "mode",
+	Usage: "frc102: sign the message behind the FRC-0102 header, raw: sign the bytes as they are, lotus: like raw, printing the signature like `lotus wallet sign`",
+	Value: envelope.ModeFRC102,
+}
+
+var signInputFlag = &cli.StringFlag{
+	Name:  "input",
+	Usage: "how to read the message argument, ps: hex, text (utf-8), file (path of the message)",
+	Value: "hex",
+}
+
 var walletSign = &cli.Command{
 	Name:  "sign",
 	Usage: "Sign a message",
@@ -201,6 +228,9 @@ var walletSign = &cli.Command{
 			Usage: "wallet index",
 			Value: 0,
 		},
+		signModeFlag,
+		signInputFlag,
+		yesFlag,
 		&cli.StringFlag{
 			Name:  "conf-path",
 			Usage: "config.yaml path",
@@ -211,7 +241,7 @@ var walletSign = &cli.Command{
 		config.InitConfig(c.String("conf-path"))
 		return nil
 	},
-	ArgsUsage: "<signing address> <hexMessage>",
+	ArgsUsage: "<signing address> <message>",
 	Action: func(cctx *cli.Context) error {
 		if !cctx.Args().Present() || cctx.NArg() != 2 {
 			return fmt.Errorf("must specify signing address and message to sign")
@@ -221,6 +251,31 @@ var walletSign = &cli.Command{
 		if err != nil {
 			return err
 		}
+
+		data, err := readSignInput(cctx.String("input"), cctx.Args().Get(1))
+		if err != nil {
+			return err
+		}
+
+		mode := cctx.String("mode")
+		payload, err := envelope.Payload(mode, data)
+		if err != nil {
+			return err
+		}
+
+		if mode != envelope.ModeFRC102 && envelope.IsCid(payload) {
+			color.Red("the message is a cid, e.g. of a chain message, signing it may authorize whatever it points to!")
+			if !cctx.Bool("yes") {
+				ok, err := util.GetConfirm("Sign it anyway?")
+				if err != nil {
+					return err
+				}
+				if !ok {
+					return xerrors.New("signing not confirmed")
+				}
+			}
+		}
+
 		nk, err := getAccount(cctx)
 		if err != nil {
 			return err
@@ -230,83 +285,133 @@ var walletSign = &cli.Command{
 			return xerrors.Errorf("The wallet address is: %s, sign address is: %s", nk.Address.String(), addr.String())
 		}
 
-		msg, err := hex.DecodeString(cctx.Args().Get(1))
+		sig, err := sigs.Sign(key.ActSigType(nk.Type), nk.PrivateKey, payload)
 		if err != nil {
 			return err
 		}
 
-		sig, err := sigs.Sign(key.ActSigType(nk.Type), nk.PrivateKey, msg)
+		if mode == envelope.ModeLotus {
+			sigBytes := append([]byte{byte(sig.Type)}, sig.Data...)
+
+			fmt.Println(hex.EncodeToString(sigBytes))
+			return nil
+		}
+
+		out, err := envelope.New(addr, mode, sig)
 		if err != nil {
 			return err
 		}
 
-		sigBytes := append([]byte{byte(sig.Type)}, sig.Data...)
+		b, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			return err
+		}
 
-		fmt.Println(hex.EncodeToString(sigBytes))
+		fmt.Println(string(b))
 		return nil
 	},
 }
 
 var walletVerify = &cli.Command{
 	Name:  "verify",
-	Usage: "Verify the signature of a message",
+	Usage: "Verify the signature of a message or a signed message file, needs no key",
 	Flags: []cli.Flag{
+		signModeFlag,
+		signInputFlag,
 		&cli.StringFlag{
-			Name:  "type",
-			Usage: "wallet type, ps: secp256k1, bls",
-			Value: "secp256k1",
+			Name:  "signature-file",
+			Usage: "json output of sign, it has the signing address, the mode and the signature",
+		},
+		&cli.StringFlag{
+			Name:  "message-file",
+			Usage: "signed message file to verify, lotus json or cbor, the signer defaults to the message sender",
+		},
+		// kept for the scripts passing them, verify needs no key
+		&cli.StringFlag{
+			Name:   "type",
+			Hidden: true,
 		},
 		&cli.IntFlag{
-			Name:  "index",
-			Usage: "wallet index",
-			Value: 0,
+			Name:   "index",
+			Hidden: true,
 		},
 		&cli.StringFlag{
 			Name:  "conf-path",
-			Usage: "config.yaml path",
+			Usage: "config.yaml path, only read to resolve id addresses with the node",
 			Value: "",
 		},
 	},
-	Before: func(c *cli.Context) error {
-		config.InitConfig(c.String("conf-path"))
-		return nil
-	},
-	ArgsUsage: "<signing address> <hexMessage> <signature>",
+	ArgsUsage: "<signing address> <message> <hexSignature> | --signature-file <file> <message> | --message-file <file> [signing address]",
 	Action: func(cctx *cli.Context) error {
-		if !cctx.Args().Present() || cctx.NArg() != 3 {
-			return fmt.Errorf("must specify signing address, message, and signature to verify")
+		if cctx.IsSet("message-file") {
+			return verifyMessageFile(cctx)
 		}
 
-		addr, err := address.NewFromString(cctx.Args().First())
-		if err != nil {
-			return err
-		}
+		var addr address.Address
+		var sig *crypto.Signature
+		var msgArg string
+		mode := cctx.String("mode")
+		if cctx.IsSet("signature-file") {
+			if cctx.NArg() != 1 {
+				return fmt.Errorf("must specify the message to verify")
+			}
+			msgArg = cctx.Args().First()
 
-		nk, err := getAccount(cctx)
-		if err != nil {
-			return err
-		}
+			b, err := os.ReadFile(cctx.String("signature-file"))
+			if err != nil {
+				return err
+			}
+			var s envelope.Signature
+			if err := json.Unmarshal(b, &s); err != nil {
+				return xerrors.Errorf("decoding signature file: %w", err)
+			}
+			if addr, sig, err = s.Decode(); err != nil {
+				return err
+			}
 
-		if nk.Address.String() != addr.String() {
-			return xerrors.Errorf("The wallet address is: %s, sign address is: %s", nk.Address.String(), addr.String())
+			if cctx.IsSet("mode") && mode != s.Mode {
+				return xerrors.Errorf("--mode %s, the signature file is signed in mode %s", mode, s.Mode)
+			}
+			mode = s.Mode
+		} else {
+			if !cctx.Args().Present() || cctx.NArg() != 3 {
+				return fmt.Errorf("must specify signing address, message, and signature to verify")
+			}
+			msgArg = cctx.Args().Get(1)
+
+			var err error
+			addr, err = address.NewFromString(cctx.Args().First())
+			if err != nil {
+				return err
+			}
+
+			sigBytes, err := hex.DecodeString(cctx.Args().Get(2))
+			if err != nil {
+				return err
+			}
+
+			sig = new(crypto.Signature)
+			if err := sig.UnmarshalBinary(sigBytes); err != nil {
+				return err
+			}
 		}
 
-		msg, err := hex.DecodeString(cctx.Args().Get(1))
+		data, err := readSignInput(cctx.String("input"), msgArg)
 		if err != nil {
 			return err
 		}
 
-		sigBytes, err := hex.DecodeString(cctx.Args().Get(2))
+		payload, err := envelope.Payload(mode, data)
 		if err != nil {
 			return err
 		}
 
-		var sig crypto.Signature
-		if err := sig.UnmarshalBinary(sigBytes); err != nil {
+		signer, err := verifySigner(cctx, addr)
+		if err != nil {
 			return err
 		}
 
-		err = sigs.Verify(&sig, addr, msg)
+		err = verify.Signature(sig, signer, payload)
 		if err != nil {
 			fmt.Println("invalid signature")
 			return err
@@ -316,6 +421,71 @@ var walletVerify = &cli.Command{
 	},
 }
 
+// readSignInput reads the message argument of sign and verify as hex, utf-8 text or the path of a file
+func readSignInput(input, arg string) ([]byte, error) {
+	switch input {
+	case "hex":
+		return hex.DecodeString(arg)
+	case "text":
+		if !utf8.ValidString(arg) {
+			return nil, xerrors.New("the message is not valid utf-8")
+		}
+		return []byte(arg), nil
+	case "file":
+		return os.ReadFile(arg)
+	default:
+		return nil, xerrors.Errorf("--input: %s, must be hex / text / file", input)
+	}
+}
+
+func verifyMessageFile(cctx *cli.Context) error {
+	b, err := os.ReadFile(cctx.String("message-file"))
+	if err != nil {
+		return err
+	}
+
+	sm, err := verify.ParseSignedMessage(b)
+	if err != nil {
+		return err
+	}
+
+	addr := sm.Message.From
+	if cctx.Args().Present() {
+		if addr, err = address.NewFromString(cctx.Args().First()); err != nil {
+			return err
+		}
+	}
+
+	signer, err := verifySigner(cctx, addr)
+	if err != nil {
+		return err
+	}
+
+	if err := verify.Message(sm, signer); err != nil {
+		fmt.Println("invalid signature")
+		return err
+	}
+	fmt.Printf("valid signature of message %s by %s\n", sm.Cid(), signer)
+	return nil
+}
+
+// verifySigner resolves the key address of addr, only id addresses need the node
+func verifySigner(cctx *cli.Context, addr address.Address) (address.Address, error) {
+	if addr.Protocol() != address.ID {
+		return verify.Signer(context.Background(), nil, addr)
+	}
+
+	config.InitConfig(cctx.String("conf-path"))
+	conf := config.Conf()
+	api, closer, err := client.NewLotusAPI(conf.Chain.RpcAddr, conf.Chain.Token)
+	if err != nil {
+		return address.Undef, err
+	}
+	defer closer()
+
+	return verify.Signer(context.Background(), api, addr)
+}
+
 var walletBalance = &cli.Command{
 	Name:      "balance",
 	Usage:     "Get account balance",
@@ -409,6 +579,11 @@ var walletTransfer = &cli.Command{
 			Usage: "specify the nonce to use",
 			Value: 0,
 		},
+		overridePolicyFlag,
+		yesFlag,
+		memoFlag,
+		maxFeeFlag,
+		nonceOffsetFlag,
 		&cli.StringFlag{
 			Name:  "type",
 			Usage: "wallet type, ps: secp256k1, bls",
@@ -445,7 +620,7 @@ var walletTransfer = &cli.Command{
 			return err
 		}
 
-		messageCid, err := send(nk, sendMessage)
+		messageCid, err := send(cctx, nk, sendMessage)
 		if err != nil {
 			log.Error(err)
 			return err
@@ -492,6 +667,11 @@ var walletSendCmd = &cli.Command{
 			Usage: "specify the nonce to use",
 			Value: 0,
 		},
+		overridePolicyFlag,
+		yesFlag,
+		memoFlag,
+		maxFeeFlag,
+		nonceOffsetFlag,
 		&cli.Uint64Flag{
 			Name:  "method",
 			Usage: "specify method to invoke",
@@ -541,7 +721,7 @@ var walletSendCmd = &cli.Command{
 			return err
 		}
 
-		messageCid, err := send(nk, sendMessage)
+		messageCid, err := send(cctx, nk, sendMessage)
 		if err != nil {
 			fmt.Println(err)
 			return err
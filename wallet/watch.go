@@ -0,0 +1,297 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/builtin"
+	"github.com/filecoin-project/go-state-types/exitcode"
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	msig2 "github.com/filecoin-project/specs-actors/v2/actors/builtin/multisig"
+	"github.com/llifezou/fil-wallet/client"
+	"github.com/llifezou/fil-wallet/config"
+	"github.com/llifezou/fil-wallet/watch"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+	"sort"
+	"time"
+)
+
+var msigWatchCmd = &cli.Command{
+	Name:      "watch",
+	Usage:     "Watch multisigs for new proposals, approvals, executions and cancellations, printed as json lines",
+	ArgsUsage: "<multisigAddress> [multisigAddress...]",
+	Flags: []cli.Flag{
+		&cli.DurationFlag{
+			Name:  "interval",
+			Usage: "how often the state of the multisigs is polled",
+			Value: 30 * time.Second,
+		},
+		&cli.StringFlag{
+			Name:  "webhook",
+			Usage: "POST every event as json to this url, overrides watch.webhook of the config",
+		},
+		&cli.StringFlag{
+			Name:  "exec",
+			Usage: "run this shell command for every event with the event json on stdin, overrides watch.exec of the config",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if !cctx.Args().Present() {
+			return fmt.Errorf("must specify the multisigs to watch")
+		}
+
+		var addrs []address.Address
+		for _, a := range cctx.Args().Slice() {
+			addr, err := address.NewFromString(a)
+			if err != nil {
+				return err
+			}
+			addrs = append(addrs, addr)
+		}
+
+		conf := config.Conf()
+		webhook, execHook := conf.Watch.Webhook, conf.Watch.Exec
+		if cctx.IsSet("webhook") {
+			webhook = cctx.String("webhook")
+		}
+		if cctx.IsSet("exec") {
+			execHook = cctx.String("exec")
+		}
+		hook := watch.NewHook(webhook, execHook)
+
+		api, closer, err := client.NewLotusAPI(conf.Chain.RpcAddr, conf.Chain.Token)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := context.Background()
+
+		emit := func(ev watch.Event) {
+			b, err := json.Marshal(ev)
+			if err != nil {
+				log.Errorw("encoding event", "err", err)
+				return
+			}
+			fmt.Println(string(b))
+
+			// a failing hook must not stop the watcher
+			if err := hook.Send(ev); err != nil {
+				log.Errorw("event hook failed", "multisig", ev.Multisig, "txId", ev.TxID, "err", err)
+			}
+		}
+
+		watchers := make([]*msigWatcher, len(addrs))
+		for i, addr := range addrs {
+			watchers[i] = &msigWatcher{addr: addr}
+		}
+
+		for {
+			for _, w := range watchers {
+				events, err := w.poll(ctx, api)
+				if err != nil {
+					// the node may be briefly unavailable, the next poll catches up
+					log.Errorw("polling multisig", "multisig", w.addr, "err", err)
+					continue
+				}
+				for _, ev := range events {
+					emit(ev)
+				}
+			}
+
+			time.Sleep(cctx.Duration("interval"))
+		}
+	},
+}
+
+type msigWatcher struct {
+	addr    address.Address
+	pending map[int64]watch.Txn
+	// head is the tipset of the last poll, nil before the first one
+	head *types.TipSet
+}
+
+// poll reads the pending transactions and compares them to the last poll, the first poll reports
+// the transactions already pending
+func (w *msigWatcher) poll(ctx context.Context, node api.FullNode) ([]watch.Event, error) {
+	info, err := inspectMsig(ctx, node, w.addr, true)
+	if err != nil {
+		return nil, err
+	}
+
+	// the head is read after the state so the outcome scan covers every message the state includes
+	head, err := node.ChainHead(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cur := make(map[int64]watch.Txn)
+	for _, tx := range info.Transactions {
+		var approved []string
+		for _, a := range tx.Approved {
+			if a.Address != "N/A" {
+				approved = append(approved, a.Address)
+			} else {
+				approved = append(approved, a.ID)
+			}
+		}
+
+		cur[tx.ID] = watch.Txn{
+			ID:           tx.ID,
+			To:           tx.To,
+			Value:        tx.Value.String(),
+			Method:       tx.Method,
+			MethodNum:    tx.MethodNum,
+			Params:       tx.Params,
+			Approved:     approved,
+			ProposalHash: tx.ProposalHash,
+		}
+	}
+
+	var events []watch.Event
+	if w.head == nil {
+		for id, txn := range cur {
+			events = append(events, watch.Event{Type: watch.TypePending, TxID: id, Txn: txn})
+		}
+		sort.Slice(events, func(i, j int) bool {
+			return events[i].TxID < events[j].TxID
+		})
+	} else {
+		// a transaction executed when it was proposed is never pending, the messages are scanned
+		// whenever the chain moved
+		var outcomes map[int64]watch.Outcome
+		if head.Height() > w.head.Height() {
+			outcomes, err = msigOutcomes(ctx, node, w.addr, w.head, head)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		events = watch.Diff(w.pending, cur, outcomes)
+	}
+
+	now := time.Now()
+	for i := range events {
+		events[i].Multisig = w.addr.String()
+		events[i].Height = head.Height()
+		events[i].Time = now
+	}
+
+	w.pending = cur
+	w.head = head
+	return events, nil
+}
+
+// msigOutcomes finds the successful approve and cancel messages sent to the multisig since the last
+// poll, an approve that applied a transaction executed it. Propose messages that applied their
+// transaction at once are returned with the transaction.
+func msigOutcomes(ctx context.Context, node api.FullNode, maddr address.Address, from, to *types.TipSet) (map[int64]watch.Outcome, error) {
+	idAddr, err := node.StateLookupID(ctx, maddr, to.Key())
+	if err != nil {
+		return nil, err
+	}
+
+	matches := []*api.MessageMatch{{To: idAddr}}
+	if maddr != idAddr {
+		matches = append(matches, &api.MessageMatch{To: maddr})
+	}
+
+	outcomes := make(map[int64]watch.Outcome)
+	for _, match := range matches {
+		cids, err := node.StateListMessages(ctx, match, to.Key(), from.Height())
+		if err != nil {
+			return nil, xerrors.Errorf("listing messages: %w", err)
+		}
+
+		for _, c := range cids {
+			msg, err := node.ChainGetMessage(ctx, c)
+			if err != nil {
+				return nil, err
+			}
+
+			switch msg.Method {
+			case builtin.MethodsMultisig.Propose, builtin.MethodsMultisig.Approve, builtin.MethodsMultisig.Cancel:
+			default:
+				continue
+			}
+
+			// only a successful message changed the transactions, a message of the last tipset has
+			// no receipt yet and is picked up by the next poll
+			lookup, err := node.StateSearchMsg(ctx, to.Key(), c, to.Height()-from.Height()+1, true)
+			if err != nil {
+				return nil, xerrors.Errorf("searching message %s: %w", c, err)
+			}
+			if lookup == nil || !lookup.Receipt.ExitCode.IsSuccess() {
+				continue
+			}
+
+			switch msg.Method {
+			case builtin.MethodsMultisig.Propose:
+				var ret msig2.ProposeReturn
+				if err := ret.UnmarshalCBOR(bytes.NewReader(lookup.Receipt.Return)); err != nil || !ret.Applied {
+					continue
+				}
+
+				var p msig2.ProposeParams
+				if err := p.UnmarshalCBOR(bytes.NewReader(msg.Params)); err != nil {
+					continue
+				}
+
+				txn := proposedTxn(ctx, node, int64(ret.TxnID), msg.From, &p)
+				outcomes[int64(ret.TxnID)] = watch.Outcome{Type: executedType(ret.Code), Cid: c.String(), ExitCode: ret.Code, Txn: &txn}
+			case builtin.MethodsMultisig.Approve:
+				var ret msig2.ApproveReturn
+				if err := ret.UnmarshalCBOR(bytes.NewReader(lookup.Receipt.Return)); err != nil || !ret.Applied {
+					continue
+				}
+
+				var p msig2.TxnIDParams
+				if err := p.UnmarshalCBOR(bytes.NewReader(msg.Params)); err != nil {
+					continue
+				}
+				outcomes[int64(p.ID)] = watch.Outcome{Type: executedType(ret.Code), Cid: c.String(), ExitCode: ret.Code}
+			case builtin.MethodsMultisig.Cancel:
+				var p msig2.TxnIDParams
+				if err := p.UnmarshalCBOR(bytes.NewReader(msg.Params)); err != nil {
+					continue
+				}
+				outcomes[int64(p.ID)] = watch.Outcome{Type: watch.TypeCancelled, Cid: c.String()}
+			}
+		}
+	}
+
+	return outcomes, nil
+}
+
+func executedType(code exitcode.ExitCode) string {
+	if code.IsSuccess() {
+		return watch.TypeExecuted
+	}
+	return watch.TypeFailed
+}
+
+// proposedTxn describes a transaction executed when it was proposed like a pending one
+func proposedTxn(ctx context.Context, node api.FullNode, id int64, proposer address.Address, p *msig2.ProposeParams) watch.Txn {
+	txn := watch.Txn{
+		ID:        id,
+		To:        p.To.String(),
+		Value:     types.FIL(p.Value).String(),
+		Method:    "Send",
+		MethodNum: p.Method,
+		Params:    fmt.Sprintf("%x", p.Params),
+		Approved:  []string{proposer.String()},
+	}
+
+	if targAct, err := node.StateGetActor(ctx, p.To, types.EmptyTSK); err != nil {
+		if p.Method != builtin.MethodSend {
+			txn.Method = "new account, unknown method"
+		}
+	} else {
+		txn.Method, txn.Params = methodInfo(targAct.Code, p.Method, p.Params)
+	}
+
+	return txn
+}
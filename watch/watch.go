@@ -0,0 +1,192 @@
+package watch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/exitcode"
+	"golang.org/x/xerrors"
+	"net/http"
+	"os"
+	"os/exec"
+	"sort"
+	"time"
+)
+
+const (
+	// TypePending reports the transactions already pending when the watcher starts
+	TypePending   = "pending"
+	TypeProposed  = "proposed"
+	TypeApproved  = "approved"
+	TypeExecuted  = "executed"
+	TypeCancelled = "cancelled"
+	// TypeFailed is a transaction that was executed but whose call failed, the exit code tells why
+	TypeFailed = "failed"
+	// TypeRemoved is a transaction that is gone without a known approve or cancel message
+	TypeRemoved = "removed"
+)
+
+// Txn is a pending transaction of a multisig as the watcher saw it
+type Txn struct {
+	ID        int64         `json:"id"`
+	To        string        `json:"to"`
+	Value     string        `json:"value"`
+	Method    string        `json:"method"`
+	MethodNum abi.MethodNum `json:"methodNum"`
+	// Params are json when they can be decoded, hex otherwise
+	Params       string   `json:"params"`
+	Approved     []string `json:"approved"`
+	ProposalHash string   `json:"proposalHash"`
+}
+
+// Outcome is the approve or cancel message that removed a transaction, or the propose message of a
+// transaction executed at once, which is never pending
+type Outcome struct {
+	Type     string
+	Cid      string
+	ExitCode exitcode.ExitCode
+	// Txn is set for a transaction executed when it was proposed
+	Txn *Txn
+}
+
+// Event is one line of the watcher output, and the body of the hooks
+type Event struct {
+	Type     string         `json:"type"`
+	Multisig string         `json:"multisig"`
+	Height   abi.ChainEpoch `json:"height"`
+	Time     time.Time      `json:"time"`
+	TxID     int64          `json:"txId"`
+	// Approvers are the signers that approved since the last poll
+	Approvers []string `json:"approvers,omitempty"`
+	// Message is the approve or cancel message that executed or cancelled the transaction
+	Message string `json:"message,omitempty"`
+	// ExitCode is the exit code of the call of an executed or failed transaction
+	ExitCode exitcode.ExitCode `json:"exitCode,omitempty"`
+	Txn      Txn               `json:"txn"`
+}
+
+// Diff compares two polls of the pending transactions of a multisig, a transaction that is gone
+// takes the type of its outcome. A transaction executed when it was proposed is reported as proposed
+// and executed. The events are ordered by transaction id.
+func Diff(prev, cur map[int64]Txn, outcomes map[int64]Outcome) []Event {
+	var events []Event
+	for id, txn := range cur {
+		old, ok := prev[id]
+		if !ok {
+			events = append(events, Event{Type: TypeProposed, TxID: id, Txn: txn})
+			continue
+		}
+
+		if added := newApprovers(old.Approved, txn.Approved); len(added) > 0 {
+			events = append(events, Event{Type: TypeApproved, TxID: id, Approvers: added, Txn: txn})
+		}
+	}
+
+	for id, txn := range prev {
+		if _, ok := cur[id]; ok {
+			continue
+		}
+
+		ev := Event{Type: TypeRemoved, TxID: id, Txn: txn}
+		if o, ok := outcomes[id]; ok {
+			ev.Type = o.Type
+			ev.Message = o.Cid
+			ev.ExitCode = o.ExitCode
+		}
+		events = append(events, ev)
+	}
+
+	for id, o := range outcomes {
+		if o.Txn == nil {
+			continue
+		}
+		if _, ok := prev[id]; ok {
+			continue
+		}
+		if _, ok := cur[id]; ok {
+			continue
+		}
+
+		events = append(events,
+			Event{Type: TypeProposed, TxID: id, Txn: *o.Txn},
+			Event{Type: o.Type, TxID: id, Message: o.Cid, ExitCode: o.ExitCode, Txn: *o.Txn})
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].TxID < events[j].TxID
+	})
+	return events
+}
+
+func newApprovers(old, cur []string) []string {
+	seen := make(map[string]bool)
+	for _, a := range old {
+		seen[a] = true
+	}
+
+	var added []string
+	for _, a := range cur {
+		if !seen[a] {
+			added = append(added, a)
+		}
+	}
+	return added
+}
+
+// Hook forwards the events to a webhook and a command, both are optional
+type Hook struct {
+	// Webhook receives every event as a json POST
+	Webhook string
+	// Exec is run by the shell for every event, with the event json on stdin
+	Exec string
+
+	client http.Client
+}
+
+func NewHook(webhook, exec string) *Hook {
+	return &Hook{
+		Webhook: webhook,
+		Exec:    exec,
+		client:  http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Send runs both hooks even when one of them fails, the errors are combined
+func (h *Hook) Send(ev Event) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	if h.Webhook != "" {
+		if err := h.post(data); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if h.Exec != "" {
+		cmd := exec.Command("sh", "-c", h.Exec)
+		cmd.Stdin = bytes.NewReader(data)
+		cmd.Stdout = os.Stderr
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			errs = append(errs, xerrors.Errorf("exec hook: %w", err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (h *Hook) post(data []byte) error {
+	resp, err := h.client.Post(h.Webhook, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return xerrors.Errorf("webhook: %w", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return xerrors.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
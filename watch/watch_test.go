@@ -0,0 +1,105 @@
+package watch
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	prev := map[int64]Txn{
+		1: {ID: 1, To: "f1xxx", Approved: []string{"f1a"}},
+		2: {ID: 2, To: "f1yyy", Approved: []string{"f1a"}},
+		3: {ID: 3, To: "f1zzz", Approved: []string{"f1b"}},
+		4: {ID: 4, To: "f1zzz", Approved: []string{"f1b"}},
+	}
+	cur := map[int64]Txn{
+		1: {ID: 1, To: "f1xxx", Approved: []string{"f1a"}},
+		2: {ID: 2, To: "f1yyy", Approved: []string{"f1a", "f1c"}},
+		5: {ID: 5, To: "f1www", Approved: []string{"f1c"}},
+	}
+	outcomes := map[int64]Outcome{
+		3: {Type: TypeExecuted, Cid: "bafy3"},
+		4: {Type: TypeFailed, Cid: "bafy4", ExitCode: 16},
+		6: {Type: TypeExecuted, Cid: "bafy6", Txn: &Txn{ID: 6, To: "f1vvv", Approved: []string{"f1a"}}},
+	}
+
+	events := Diff(prev, cur, outcomes)
+	if len(events) != 6 {
+		t.Fatalf("expected 6 events, got %+v", events)
+	}
+
+	if events[0].TxID != 2 || events[0].Type != TypeApproved || len(events[0].Approvers) != 1 || events[0].Approvers[0] != "f1c" {
+		t.Fatalf("unexpected approval: %+v", events[0])
+	}
+	if events[1].TxID != 3 || events[1].Type != TypeExecuted || events[1].Message != "bafy3" || events[1].Txn.To != "f1zzz" {
+		t.Fatalf("unexpected execution: %+v", events[1])
+	}
+	if events[2].TxID != 4 || events[2].Type != TypeFailed || events[2].ExitCode != 16 {
+		t.Fatalf("unexpected failure: %+v", events[2])
+	}
+	if events[3].TxID != 5 || events[3].Type != TypeProposed {
+		t.Fatalf("unexpected proposal: %+v", events[3])
+	}
+	if events[4].TxID != 6 || events[4].Type != TypeProposed || events[4].Txn.To != "f1vvv" {
+		t.Fatalf("unexpected proposal of the transaction executed at once: %+v", events[4])
+	}
+	if events[5].TxID != 6 || events[5].Type != TypeExecuted || events[5].Message != "bafy6" {
+		t.Fatalf("unexpected execution of the transaction executed at once: %+v", events[5])
+	}
+
+	delete(prev, 4)
+	if events := Diff(prev, cur, outcomes); len(events) != 5 {
+		t.Fatalf("expected the outcome of an unknown pending transaction to be ignored, got %+v", events)
+	}
+
+	if events := Diff(cur, cur, nil); len(events) != 0 {
+		t.Fatalf("expected no events without changes, got %+v", events)
+	}
+}
+
+func TestHookWebhook(t *testing.T) {
+	var got Event
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &got); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	ev := Event{Type: TypeProposed, Multisig: "f2xxx", TxID: 7, Txn: Txn{ID: 7, Method: "Send"}}
+	if err := NewHook(srv.URL, "").Send(ev); err != nil {
+		t.Fatal(err)
+	}
+	if got.Type != TypeProposed || got.TxID != 7 || got.Txn.Method != "Send" {
+		t.Fatalf("unexpected webhook body: %+v", got)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+
+	if err := NewHook(failing.URL, "").Send(ev); err == nil {
+		t.Fatal("expected an error for a failing webhook")
+	}
+
+	// the exec hook still runs when the webhook fails
+	out := filepath.Join(t.TempDir(), "event.json")
+	if err := NewHook(failing.URL, "cat > "+out).Send(ev); err == nil {
+		t.Fatal("expected an error for a failing webhook")
+	}
+	body, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("exec hook didn't run: %s", err)
+	}
+	var execd Event
+	if err := json.Unmarshal(body, &execd); err != nil || execd.TxID != 7 {
+		t.Fatalf("unexpected exec hook input: %s", body)
+	}
+}